@@ -0,0 +1,419 @@
+package pg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Append head of packet with cid as Command ID using default pg version to dst
+// Returns extended dst and the offset of appended packet to be passed to AppendPktEnd
+func AppendPktStart(dst []byte, cid CmdID) ([]byte, int) {
+	return AppendPktStartVer(dst, PgVer, cid)
+}
+
+// Append head of packet with cid as Command ID and ver as pg version to dst
+// Returns extended dst and the offset of appended packet to be passed to AppendPktEnd
+func AppendPktStartVer(dst []byte, ver byte, cid CmdID) ([]byte, int) {
+	start := len(dst)
+	return append(dst, Head1, Head2, ver, cid, 0, 0), start
+}
+
+// Set data length of packet appended at dst[start:] then append its checksum
+func AppendPktEnd(dst []byte, start int) []byte {
+	binary.BigEndian.PutUint16(dst[start+int(IdxDlen):], uint16(len(dst)-start-int(IdxData)))
+	return append(dst, Chksum(dst[start:]))
+}
+
+// Append packet with cid as Command ID and data to dst
+func AppendPkt(dst []byte, cid CmdID, data []byte) []byte {
+	dst, start := AppendPktStart(dst, cid)
+	dst = append(dst, data...)
+	return AppendPktEnd(dst, start)
+}
+
+// Append handshake packet
+func AppendHandshake(dst []byte, msg []byte) []byte {
+	return AppendPkt(dst, CmdHandshake, msg)
+}
+
+// Append uplink info request packet
+func AppendUinfoReq(dst []byte, rb DeviceInfoRB) []byte {
+	dst, start := AppendPktStart(dst, CmdUplinkInfo)
+	dst = append(dst, rb)
+	return AppendPktEnd(dst, start)
+}
+
+// Append uplink info response packet
+func AppendUinfoResp(dst []byte, rb DeviceInfoRB, resp string) []byte {
+	dst, start := AppendPktStart(dst, CmdUplinkInfo)
+	dst = append(dst, rb)
+	dst = append(dst, resp...)
+	return AppendPktEnd(dst, start)
+}
+
+// Append network reset request packet
+func AppendNetResetReq(dst []byte, rb NetRstRB) []byte {
+	dst, start := AppendPktStart(dst, CmdNetworkReset)
+	dst = append(dst, rb)
+	return AppendPktEnd(dst, start)
+}
+
+// Append network status report packet
+func AppendNetStatusReport(dst []byte, r NetstatData) []byte {
+	dst, start := AppendPktStart(dst, CmdNetworkStatus)
+	dst = append(dst, r)
+	return AppendPktEnd(dst, start)
+}
+
+// Append time synchronization request packet
+func AppendTsyncReq(dst []byte, rb TimesyncRB) []byte {
+	dst, start := AppendPktStart(dst, CmdTimeSync)
+	dst = append(dst, rb)
+	return AppendPktEnd(dst, start)
+}
+
+// Append time synchronization response packet
+func AppendTsyncResp(dst []byte, rb TimesyncRB, tm time.Time) []byte {
+	dst, start := AppendPktStart(dst, CmdTimeSync)
+	dst = append(dst, rb, byte(tm.Year()-100), byte(tm.Month()), byte(tm.Day()), byte(tm.Weekday()),
+		byte(tm.Hour()), byte(tm.Minute()), byte(tm.Second()))
+	return AppendPktEnd(dst, start)
+}
+
+// Append fixed-point DE data representation of real value to dst
+// Returns dst unchanged with error when v can't be represented with scale and offset
+func AppendFixed(dst []byte, v float64, scale float32, offset float32) ([]byte, error) {
+	raw, err := FixedRaw(v, scale, offset)
+	if err != nil {
+		return dst, err
+	}
+	return appendFixedRaw(dst, raw, scale, offset), nil
+}
+
+// Append fixed-point DE data with raw value to dst
+func appendFixedRaw(dst []byte, raw int32, scale float32, offset float32) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(raw))
+	dst = binary.BigEndian.AppendUint32(dst, math.Float32bits(scale))
+	return binary.BigEndian.AppendUint32(dst, math.Float32bits(offset))
+}
+
+// Append DE packet head with data length dlen to dst
+func appendDEHead(dst []byte, g DEGroup, id byte, t DEtype, dlen uint16) []byte {
+	dst = append(dst, byte(g), id, byte(t))
+	return binary.BigEndian.AppendUint16(dst, dlen)
+}
+
+// Append DE packet with numeric data encoded in fixed data length of t to dst
+func appendDEFixed(dst []byte, g DEGroup, id byte, t DEtype, dlen uint16, data uint32) []byte {
+	dlen = EnforceDElen(t, dlen)
+	dst = appendDEHead(dst, g, id, t, dlen)
+	switch dlen {
+	case 1:
+		dst = append(dst, byte(data))
+	case 2:
+		dst = binary.BigEndian.AppendUint16(dst, uint16(data))
+	case 4:
+		dst = binary.BigEndian.AppendUint32(dst, data)
+	}
+	return dst
+}
+
+// Append DE packet to dst
+// Raw, String and Fixed use dep.DataRaw while other types use dep.Data
+func AppendDEP(dst []byte, dep DePkt) []byte {
+	if !DEtypeUsesRaw(dep.Dtype) {
+		return appendDEFixed(dst, dep.Group, dep.Id, dep.Dtype, dep.Dlen, dep.Data)
+	}
+	dst = appendDEHead(dst, dep.Group, dep.Id, dep.Dtype, dep.Dlen)
+	return append(dst, dep.DataRaw[:dep.Dlen]...)
+}
+
+// Append packet with cid as Command ID carrying a single DE with numeric data to dst
+func appendDEFixedPkt(dst []byte, cid CmdID, g DEGroup, id byte, t DEtype, data uint32) []byte {
+	dst, start := AppendPktStart(dst, cid)
+	dst = appendDEFixed(dst, g, id, t, 0, data)
+	return AppendPktEnd(dst, start)
+}
+
+// Append packet with cid as Command ID carrying a single Fixed-point DE to dst
+func appendDEFixedPointPkt(dst []byte, cid CmdID, g DEGroup, id byte, data float64, scale float32, offset float32) []byte {
+	dst, start := AppendPktStart(dst, cid)
+	dst = appendDEHead(dst, g, id, DEtypeFixed, LenDeFixed)
+	raw, _ := FixedRaw(data, scale, offset)
+	dst = appendFixedRaw(dst, raw, scale, offset)
+	return AppendPktEnd(dst, start)
+}
+
+// Append packet with cid as Command ID carrying a single String DE to dst
+func appendDEStrPkt(dst []byte, cid CmdID, g DEGroup, id byte, data string) []byte {
+	dst, start := AppendPktStart(dst, cid)
+	dst = appendDEHead(dst, g, id, DEtypeString, uint16(len(data)))
+	dst = append(dst, data...)
+	return AppendPktEnd(dst, start)
+}
+
+// Append packet with cid as Command ID carrying all DE in depList to dst
+func appendDEListPkt(dst []byte, cid CmdID, depList []DePkt) []byte {
+	dst, start := AppendPktStart(dst, cid)
+	for _, dep := range depList {
+		dst = AppendDEP(dst, dep)
+	}
+	return AppendPktEnd(dst, start)
+}
+
+func boolData(data bool) uint32 {
+	if data {
+		return 1
+	}
+	return 0
+}
+
+// Append DE set packet to dst
+func AppendDES(dst []byte, g DEGroup, id byte, t DEtype, dlen uint16, data []byte) []byte {
+	dst, start := AppendPktStart(dst, CmdDESet)
+	dlen = EnforceDElen(t, dlen)
+	dst = appendDEHead(dst, g, id, t, dlen)
+	dst = append(dst, data[:dlen]...)
+	return AppendPktEnd(dst, start)
+}
+
+// Append DE set packet: Raw
+func AppendDeSetRaw(dst []byte, g DEGroup, id byte, data []byte) []byte {
+	return AppendDES(dst, g, id, DEtypeRaw, uint16(len(data)), data)
+}
+
+// Append DE set packet: String
+func AppendDeSetStr(dst []byte, g DEGroup, id byte, data string) []byte {
+	return appendDEStrPkt(dst, CmdDESet, g, id, data)
+}
+
+// Append DE set packet: Boolean
+func AppendDeSetBool(dst []byte, g DEGroup, id byte, data bool) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeBool, boolData(data))
+}
+
+// Append DE set packet: Enumeration
+func AppendDeSetEnum(dst []byte, g DEGroup, id byte, data byte) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeEnum, uint32(data))
+}
+
+// Append DE set packet: Unsigned integer
+func AppendDeSetUint(dst []byte, g DEGroup, id byte, data uint32) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeUint, data)
+}
+
+// Append DE set packet: 1-byte bitmap
+func AppendDeSetBmap1(dst []byte, g DEGroup, id byte, data byte) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeBmap1, uint32(data))
+}
+
+// Append DE set packet: 2-byte bitmap
+func AppendDeSetBmap2(dst []byte, g DEGroup, id byte, data uint16) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeBmap2, uint32(data))
+}
+
+// Append DE set packet: 4-byte bitmap
+func AppendDeSetBmap4(dst []byte, g DEGroup, id byte, data uint32) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeBmap4, data)
+}
+
+// Append DE set packet: 8-bit signed integer
+func AppendDeSetInt8(dst []byte, g DEGroup, id byte, data int8) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeInt8, uint32(uint8(data)))
+}
+
+// Append DE set packet: 16-bit signed integer
+func AppendDeSetInt16(dst []byte, g DEGroup, id byte, data int16) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeInt16, uint32(uint16(data)))
+}
+
+// Append DE set packet: 32-bit signed integer
+func AppendDeSetInt32(dst []byte, g DEGroup, id byte, data int32) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeInt32, uint32(data))
+}
+
+// Append DE set packet: 32-bit float
+func AppendDeSetFloat(dst []byte, g DEGroup, id byte, data float32) []byte {
+	return appendDEFixedPkt(dst, CmdDESet, g, id, DEtypeFloat, math.Float32bits(data))
+}
+
+// Append DE set packet: Fixed-point, data is encoded as round((data-offset)/scale)
+// Data rejected by FixedRaw is encoded with the saturated raw value it returns
+func AppendDeSetFixed(dst []byte, g DEGroup, id byte, data float64, scale float32, offset float32) []byte {
+	return appendDEFixedPointPkt(dst, CmdDESet, g, id, data, scale, offset)
+}
+
+// Append DE set packet containing all DE in depList
+func AppendDESList(dst []byte, depList []DePkt) []byte {
+	return appendDEListPkt(dst, CmdDESet, depList)
+}
+
+// Append DE report packet to dst
+func AppendDER(dst []byte, g DEGroup, id byte, t DEtype, dlen uint16, data []byte) []byte {
+	dst, start := AppendPktStart(dst, CmdDEReport)
+	dlen = EnforceDElen(t, dlen)
+	dst = appendDEHead(dst, g, id, t, dlen)
+	dst = append(dst, data[:dlen]...)
+	return AppendPktEnd(dst, start)
+}
+
+// Append DE report packet: Raw
+func AppendDeRepRaw(dst []byte, g DEGroup, id byte, data []byte) []byte {
+	return AppendDER(dst, g, id, DEtypeRaw, uint16(len(data)), data)
+}
+
+// Append DE report packet: String
+func AppendDeRepStr(dst []byte, g DEGroup, id byte, data string) []byte {
+	return appendDEStrPkt(dst, CmdDEReport, g, id, data)
+}
+
+// Append DE report packet: Boolean
+func AppendDeRepBool(dst []byte, g DEGroup, id byte, data bool) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeBool, boolData(data))
+}
+
+// Append DE report packet: Enumeration
+func AppendDeRepEnum(dst []byte, g DEGroup, id byte, data byte) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeEnum, uint32(data))
+}
+
+// Append DE report packet: Unsigned integer
+func AppendDeRepUint(dst []byte, g DEGroup, id byte, data uint32) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeUint, data)
+}
+
+// Append DE report packet: 1-byte bitmap
+func AppendDeRepBmap1(dst []byte, g DEGroup, id byte, data byte) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeBmap1, uint32(data))
+}
+
+// Append DE report packet: 2-byte bitmap
+func AppendDeRepBmap2(dst []byte, g DEGroup, id byte, data uint16) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeBmap2, uint32(data))
+}
+
+// Append DE report packet: 4-byte bitmap
+func AppendDeRepBmap4(dst []byte, g DEGroup, id byte, data uint32) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeBmap4, data)
+}
+
+// Append DE report packet: 8-bit signed integer
+func AppendDeRepInt8(dst []byte, g DEGroup, id byte, data int8) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeInt8, uint32(uint8(data)))
+}
+
+// Append DE report packet: 16-bit signed integer
+func AppendDeRepInt16(dst []byte, g DEGroup, id byte, data int16) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeInt16, uint32(uint16(data)))
+}
+
+// Append DE report packet: 32-bit signed integer
+func AppendDeRepInt32(dst []byte, g DEGroup, id byte, data int32) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeInt32, uint32(data))
+}
+
+// Append DE report packet: 32-bit float
+func AppendDeRepFloat(dst []byte, g DEGroup, id byte, data float32) []byte {
+	return appendDEFixedPkt(dst, CmdDEReport, g, id, DEtypeFloat, math.Float32bits(data))
+}
+
+// Append DE report packet: Fixed-point, data is encoded as round((data-offset)/scale)
+// Data rejected by FixedRaw is encoded with the saturated raw value it returns
+func AppendDeRepFixed(dst []byte, g DEGroup, id byte, data float64, scale float32, offset float32) []byte {
+	return appendDEFixedPointPkt(dst, CmdDEReport, g, id, data, scale, offset)
+}
+
+// Append DE report packet containing all DE in depList
+func AppendDERList(dst []byte, depList []DePkt) []byte {
+	return appendDEListPkt(dst, CmdDEReport, depList)
+}
+
+// Append DE fault acknowledgement packet
+func AppendDeFaultAck(dst []byte, g DEGroup, id byte) []byte {
+	dst, start := AppendPktStart(dst, CmdDEFault)
+	dst = append(dst, byte(g), id)
+	return AppendPktEnd(dst, start)
+}
+
+// Append DE fault report packet
+func AppendDeFaultRep(dst []byte, g DEGroup, id byte, f DEF) []byte {
+	dst, start := AppendPktStart(dst, CmdDEFault)
+	dst = append(dst, byte(g), id, f)
+	return AppendPktEnd(dst, start)
+}
+
+// Append schedule execution report packet
+func AppendSchExecReport(dst []byte, schId byte) []byte {
+	dst, start := AppendPktStart(dst, CmdSchedule)
+	dst = append(dst, schId)
+	return AppendPktEnd(dst, start)
+}
+
+// Append schedule set packet checked by ValidateSchList
+// Returns dst unchanged with ErrSchedule for empty or invalid schList
+func AppendSchSet(dst []byte, schList []SchPkt) ([]byte, error) {
+	if len(schList) == 0 {
+		return dst, fmt.Errorf("%w: empty schedule set, erase all schedules instead", ErrSchedule)
+	}
+	if len(schList) > math.MaxUint8 {
+		return dst, fmt.Errorf("%w: %d schedules", ErrSchedule, len(schList))
+	}
+	if err := ValidateSchList(schList); err != nil {
+		return dst, err
+	}
+	return appendSchSet(dst, schList), nil
+}
+
+// Append schedule set packet without checking schList
+func appendSchSet(dst []byte, schList []SchPkt) []byte {
+	dst, start := AppendPktStart(dst, CmdSchedule)
+	dst = append(dst, byte(len(schList)))
+	for _, sch := range schList {
+		dst = AppendSchPkt(dst, sch)
+	}
+	return AppendPktEnd(dst, start)
+}
+
+// Append schedule header and DE of sch to dst
+func AppendSchPkt(dst []byte, sch SchPkt) []byte {
+	dst = append(dst, sch.Id, sch.Weekdays, sch.Hour, sch.Minute)
+	return AppendDEP(dst, sch.Dep)
+}
+
+// Append software update simple reply packet
+func AppendSwupSrep(dst []byte, srep SwupSrep) []byte {
+	dst, start := AppendPktStart(dst, CmdSwUpdate)
+	dst = append(dst, srep)
+	return AppendPktEnd(dst, start)
+}
+
+// Append software update chunk size set packet
+func AppendSwupSetChunksz(dst []byte, chunksz uint16) []byte {
+	dst, start := AppendPktStart(dst, CmdSwUpdate)
+	dst = binary.BigEndian.AppendUint16(dst, chunksz)
+	return AppendPktEnd(dst, start)
+}
+
+// Append software update status packet
+func AppendSwupStatus(dst []byte, finished bool, success bool, err SwupErr) []byte {
+	dst, start := AppendPktStart(dst, CmdSwUpdate)
+	dst = append(dst, byte(boolData(finished)), byte(boolData(success)), err)
+	return AppendPktEnd(dst, start)
+}
+
+// Append software update chunk request packet
+func AppendSwupChunkReq(dst []byte, chunkidx uint32) []byte {
+	dst, start := AppendPktStart(dst, CmdSwUpdate)
+	dst = binary.BigEndian.AppendUint32(dst, chunkidx)
+	return AppendPktEnd(dst, start)
+}
+
+// Append software update chunk packet
+func AppendSwupChunk(dst []byte, chunkidx uint32, chunk []byte) []byte {
+	dst, start := AppendPktStart(dst, CmdSwUpdate)
+	dst = binary.BigEndian.AppendUint32(dst, chunkidx)
+	dst = append(dst, chunk...)
+	return AppendPktEnd(dst, start)
+}
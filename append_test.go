@@ -0,0 +1,110 @@
+package pg
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	SetVer(0)
+	depList := []DePkt{
+		{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1},
+		{Group: DegInfo, Id: 2, Dtype: DEtypeString, Dlen: 3, DataRaw: []byte("abc")},
+		{Group: DegSensor, Id: 3, Dtype: DEtypeFixed, Dlen: LenDeFixed, DataRaw: mustFixed(21.5, 0.5, 0)},
+	}
+	schList := []SchPkt{{Id: 1, Weekdays: 0b0111110, Hour: 7, Minute: 30, Dep: depList[0]}}
+	prefix := []byte("prefix")
+	tm := time.Date(2024, time.March, 5, 6, 7, 8, 0, time.UTC)
+	// Reference packet built without Append helpers
+	pkt := func(cid CmdID, data ...byte) []byte {
+		p := Create(cid)
+		p.Append(data)
+		return p.Build().Buf
+	}
+	cases := []struct {
+		mk  []byte
+		app func([]byte) []byte
+	}{
+		{MkDES(DegControl, 1, DEtypeUint, 9, []byte{1, 2, 3, 4}), func(d []byte) []byte { return AppendDES(d, DegControl, 1, DEtypeUint, 9, []byte{1, 2, 3, 4}) }},
+		{MkDeSetRaw(DegInfo, 1, []byte{9, 8}), func(d []byte) []byte { return AppendDeSetRaw(d, DegInfo, 1, []byte{9, 8}) }},
+		{MkDeSetStr(DegInfo, 1, "Benchmark"), func(d []byte) []byte { return AppendDeSetStr(d, DegInfo, 1, "Benchmark") }},
+		{MkDeSetBool(DegControl, 1, true), func(d []byte) []byte { return AppendDeSetBool(d, DegControl, 1, true) }},
+		{MkDeSetEnum(DegControl, 2, 3), func(d []byte) []byte { return AppendDeSetEnum(d, DegControl, 2, 3) }},
+		{MkDeSetUint(DegControl, 3, 0xdeadbeef), func(d []byte) []byte { return AppendDeSetUint(d, DegControl, 3, 0xdeadbeef) }},
+		{MkDeSetBmap1(DegControl, 4, 0x81), func(d []byte) []byte { return AppendDeSetBmap1(d, DegControl, 4, 0x81) }},
+		{MkDeSetBmap2(DegControl, 5, 0x8001), func(d []byte) []byte { return AppendDeSetBmap2(d, DegControl, 5, 0x8001) }},
+		{MkDeSetBmap4(DegControl, 6, 0x80000001), func(d []byte) []byte { return AppendDeSetBmap4(d, DegControl, 6, 0x80000001) }},
+		{MkDeSetInt8(DegControl, 7, -5), func(d []byte) []byte { return AppendDeSetInt8(d, DegControl, 7, -5) }},
+		{MkDeSetInt16(DegControl, 8, -300), func(d []byte) []byte { return AppendDeSetInt16(d, DegControl, 8, -300) }},
+		{MkDeSetInt32(DegControl, 9, -70000), func(d []byte) []byte { return AppendDeSetInt32(d, DegControl, 9, -70000) }},
+		{MkDeSetFloat(DegControl, 10, 1.5), func(d []byte) []byte { return AppendDeSetFloat(d, DegControl, 10, 1.5) }},
+		{MkDeSetFixed(DegControl, 11, 21.5, 0.5, 0), func(d []byte) []byte { return AppendDeSetFixed(d, DegControl, 11, 21.5, 0.5, 0) }},
+		{MkDESList(depList), func(d []byte) []byte { return AppendDESList(d, depList) }},
+		{MkDER(DegSensor, 1, DEtypeEnum, 0, []byte{4}), func(d []byte) []byte { return AppendDER(d, DegSensor, 1, DEtypeEnum, 0, []byte{4}) }},
+		{MkDeRepRaw(DegInfo, 1, []byte{9, 8}), func(d []byte) []byte { return AppendDeRepRaw(d, DegInfo, 1, []byte{9, 8}) }},
+		{MkDeRepStr(DegInfo, 1, "Benchmark"), func(d []byte) []byte { return AppendDeRepStr(d, DegInfo, 1, "Benchmark") }},
+		{MkDeRepBool(DegSensor, 1, false), func(d []byte) []byte { return AppendDeRepBool(d, DegSensor, 1, false) }},
+		{MkDeRepEnum(DegSensor, 2, 3), func(d []byte) []byte { return AppendDeRepEnum(d, DegSensor, 2, 3) }},
+		{MkDeRepUint(DegSensor, 3, 42), func(d []byte) []byte { return AppendDeRepUint(d, DegSensor, 3, 42) }},
+		{MkDeRepBmap1(DegSensor, 4, 0x81), func(d []byte) []byte { return AppendDeRepBmap1(d, DegSensor, 4, 0x81) }},
+		{MkDeRepBmap2(DegSensor, 5, 0x8001), func(d []byte) []byte { return AppendDeRepBmap2(d, DegSensor, 5, 0x8001) }},
+		{MkDeRepBmap4(DegSensor, 6, 0x80000001), func(d []byte) []byte { return AppendDeRepBmap4(d, DegSensor, 6, 0x80000001) }},
+		{MkDeRepInt8(DegSensor, 7, -5), func(d []byte) []byte { return AppendDeRepInt8(d, DegSensor, 7, -5) }},
+		{MkDeRepInt16(DegSensor, 8, -300), func(d []byte) []byte { return AppendDeRepInt16(d, DegSensor, 8, -300) }},
+		{MkDeRepInt32(DegSensor, 9, -70000), func(d []byte) []byte { return AppendDeRepInt32(d, DegSensor, 9, -70000) }},
+		{MkDeRepFloat(DegSensor, 10, 1.5), func(d []byte) []byte { return AppendDeRepFloat(d, DegSensor, 10, 1.5) }},
+		{MkDeRepFixed(DegSensor, 11, -12.3, 0.1, -40), func(d []byte) []byte { return AppendDeRepFixed(d, DegSensor, 11, -12.3, 0.1, -40) }},
+		{MkDERList(depList), func(d []byte) []byte { return AppendDERList(d, depList) }},
+		{MkDeFaultRep(DegSensor, 1, DefBroken), func(d []byte) []byte { return AppendDeFaultRep(d, DegSensor, 1, DefBroken) }},
+		{MkSchExecReport(3), func(d []byte) []byte { return AppendSchExecReport(d, 3) }},
+		{MkSchSet(schList), func(d []byte) []byte { d, _ = AppendSchSet(d, schList); return d }},
+		{MkSwupChunkReq(7), func(d []byte) []byte { return AppendSwupChunkReq(d, 7) }},
+		{MkSwupChunk(7, []byte("chunk")), func(d []byte) []byte { return AppendSwupChunk(d, 7, []byte("chunk")) }},
+		{MkHandshake([]byte("pg")), func(d []byte) []byte { return AppendPkt(d, CmdHandshake, []byte("pg")) }},
+		{pkt(CmdHandshake, 'p', 'g'), func(d []byte) []byte { return AppendHandshake(d, []byte("pg")) }},
+		{pkt(CmdUplinkInfo, DeviceName), func(d []byte) []byte { return AppendUinfoReq(d, DeviceName) }},
+		{pkt(CmdUplinkInfo, DeviceName, 'S', 'N'), func(d []byte) []byte { return AppendUinfoResp(d, DeviceName, "SN") }},
+		{pkt(CmdNetworkReset, NetAP), func(d []byte) []byte { return AppendNetResetReq(d, NetAP) }},
+		{pkt(CmdNetworkStatus, NetstatOk), func(d []byte) []byte { return AppendNetStatusReport(d, NetstatOk) }},
+		{pkt(CmdTimeSync, TsyncLocal), func(d []byte) []byte { return AppendTsyncReq(d, TsyncLocal) }},
+		{pkt(CmdTimeSync, TsyncLocal, 0x84, 3, 5, 2, 6, 7, 8), func(d []byte) []byte { return AppendTsyncResp(d, TsyncLocal, tm) }},
+		{pkt(CmdDEFault, byte(DegSensor), 1), func(d []byte) []byte { return AppendDeFaultAck(d, DegSensor, 1) }},
+		{pkt(CmdSwUpdate, SrepAccept), func(d []byte) []byte { return AppendSwupSrep(d, SrepAccept) }},
+		{pkt(CmdSwUpdate, 0x01, 0x00), func(d []byte) []byte { return AppendSwupSetChunksz(d, 256) }},
+		{pkt(CmdSwUpdate, 1, 0, SwupErrConn), func(d []byte) []byte { return AppendSwupStatus(d, true, false, SwupErrConn) }},
+	}
+	for i, c := range cases {
+		got := c.app(append([]byte{}, prefix...))
+		if !bytes.Equal(got[:len(prefix)], prefix) || !bytes.Equal(got[len(prefix):], c.mk) {
+			t.Errorf("append %d: expected %x but got %x", i, c.mk, got[len(prefix):])
+		}
+	}
+
+	badSch := [][]SchPkt{nil, {{Id: 1, Hour: 24}}, {schList[0], schList[0]}, make([]SchPkt, 256)}
+	for _, list := range badSch {
+		if got, err := AppendSchSet(prefix, list); !errors.Is(err, ErrSchedule) || !bytes.Equal(got, prefix) {
+			t.Errorf("%d schedules: %x %v", len(list), got, err)
+		}
+	}
+
+	buf := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = AppendDeRepStr(buf[:0], DegInfo, 1, "Benchmark")
+		buf = AppendDeRepFixed(buf, DegSensor, 1, 20, 0.1, -40)
+		buf = AppendDERList(buf, depList)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocation but got %g", allocs)
+	}
+
+	SetVer(3)
+	defer SetVer(0)
+	if p, err := Parse(AppendDeSetUint(nil, DegControl, 1, 1)); err != nil || p.Ver != 3 {
+		t.Error(p, err)
+	}
+	if p, err := Parse(AppendPktEnd(AppendPktStartVer(nil, 5, CmdHandshake))); err != nil || p.Ver != 5 {
+		t.Error(p, err)
+	}
+}
@@ -0,0 +1,83 @@
+package pg
+
+// Check that DE data length fits in DataRaw, so hand-built DE packets are safe to append
+func (p DePkt) checkDlen() error {
+	if DEtypeUsesRaw(p.Dtype) && int(p.Dlen) > len(p.DataRaw) {
+		return &ParseError{Err: ErrLenMismatch, Offset: int(IdxDEPdlen), Field: "DE dlen", Expected: len(p.DataRaw), Actual: int(p.Dlen)}
+	}
+	return nil
+}
+
+// Append wire form of packet built from its version, Command ID and data to dst
+func (p BasePkt) AppendBinary(dst []byte) ([]byte, error) {
+	if err := p.checkDataLen(); err != nil {
+		return dst, err
+	}
+	dst, start := AppendPktStartVer(dst, p.Ver, p.CommandID)
+	dst = append(dst, p.Data...)
+	return AppendPktEnd(dst, start), nil
+}
+
+// Wire form of packet built from its version, Command ID and data
+func (p BasePkt) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(make([]byte, 0, int(LenPktMin)+len(p.Data)))
+}
+
+// Parse wire form of packet into p owning a copy of data
+func (p *BasePkt) UnmarshalBinary(data []byte) error {
+	return ParseInto(p, data)
+}
+
+// Append standalone DE sub-packet to dst
+func (p DePkt) AppendBinary(dst []byte) ([]byte, error) {
+	if err := p.checkDlen(); err != nil {
+		return dst, err
+	}
+	return AppendDEP(dst, p), nil
+}
+
+// Standalone DE sub-packet
+func (p DePkt) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+// Parse standalone DE sub-packet into p owning a copy of data
+// Bytes after the DE sub-packet are rejected
+func (p *DePkt) UnmarshalBinary(data []byte) error {
+	dep, err := ParseDEP(data)
+	if err != nil {
+		return err
+	}
+	if len(dep.Buf) != len(data) {
+		return &ParseError{Err: ErrLenMismatch, Offset: len(dep.Buf), Field: "trailing bytes after DE", Expected: len(dep.Buf), Actual: len(data)}
+	}
+	*p = dep
+	return nil
+}
+
+// Append standalone schedule sub-packet, schedule header followed by its DE, to dst
+func (p SchPkt) AppendBinary(dst []byte) ([]byte, error) {
+	if err := p.Dep.checkDlen(); err != nil {
+		return dst, err
+	}
+	return AppendSchPkt(dst, p), nil
+}
+
+// Standalone schedule sub-packet
+func (p SchPkt) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+// Parse standalone schedule sub-packet into p owning a copy of its DE
+// Bytes after the schedule sub-packet are rejected
+func (p *SchPkt) UnmarshalBinary(data []byte) error {
+	sch, err := parseSchPkt(data)
+	if err != nil {
+		return err
+	}
+	if n := int(LenSchHead) + len(sch.Dep.Buf); n != len(data) {
+		return &ParseError{Err: ErrLenMismatch, Offset: n, Field: "trailing bytes after schedule", Expected: n, Actual: len(data)}
+	}
+	*p = sch
+	return nil
+}
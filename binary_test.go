@@ -0,0 +1,87 @@
+package pg
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = BasePkt{}
+	_ encoding.BinaryUnmarshaler = &BasePkt{}
+	_ encoding.BinaryMarshaler   = DePkt{}
+	_ encoding.BinaryUnmarshaler = &DePkt{}
+	_ encoding.BinaryMarshaler   = SchPkt{}
+	_ encoding.BinaryUnmarshaler = &SchPkt{}
+)
+
+func TestBinary(t *testing.T) {
+	SetVer(3)
+	defer SetVer(0)
+	for _, buf := range fuzzSeedPkts() {
+		var p BasePkt
+		if err := p.UnmarshalBinary(buf); err != nil {
+			t.Fatal(err)
+		}
+		out, err := p.MarshalBinary()
+		if err != nil || !bytes.Equal(out, buf) {
+			t.Errorf("expected %x but got %x %v", buf, out, err)
+		}
+		prefix := []byte{1, 2}
+		out, err = p.AppendBinary(prefix)
+		if err != nil || !bytes.Equal(out[:2], prefix) || !bytes.Equal(out[2:], buf) {
+			t.Errorf("appended %x %v", out, err)
+		}
+	}
+
+	// Hand-built packet gets header and checksum
+	p := BasePkt{Ver: 1, CommandID: CmdSchedule, DataLen: 1, Data: []byte{4}}
+	out, err := p.MarshalBinary()
+	if err != nil || !bytes.Equal(out, []byte{Head1, Head2, 1, CmdSchedule, 0, 1, 4, Chksum([]byte{Head1, Head2, 1, CmdSchedule, 0, 1, 4})}) {
+		t.Errorf("%x %v", out, err)
+	}
+	p.DataLen = 2
+	if _, err = p.MarshalBinary(); !errors.Is(err, ErrLenMismatch) {
+		t.Error(err)
+	}
+
+	sch := SchPkt{Id: 2, Weekdays: 0b0111110, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeString, Dlen: 2, DataRaw: []byte("hi")}}
+	out, err = sch.MarshalBinary()
+	if err != nil || !bytes.Equal(out, []byte{2, 0b0111110, 7, 30, byte(DegControl), 1, byte(DEtypeString), 0, 2, 'h', 'i'}) {
+		t.Errorf("%x %v", out, err)
+	}
+	var schBack SchPkt
+	if err = schBack.UnmarshalBinary(out); err != nil || schBack.String() != sch.String() {
+		t.Error(schBack, err)
+	}
+	out[len(out)-1] = '!'
+	if schBack.Dep.DataRaw[1] != 'i' {
+		t.Error("schedule DE shares data")
+	}
+
+	var dep DePkt
+	if err = dep.UnmarshalBinary(out[LenSchHead:]); err != nil || dep.Dlen != 2 || string(dep.DataRaw) != "h!" {
+		t.Error(dep, err)
+	}
+	depOut, err := dep.MarshalBinary()
+	if err != nil || !bytes.Equal(depOut, out[LenSchHead:]) {
+		t.Errorf("%x %v", depOut, err)
+	}
+
+	bad := []func() error{
+		func() error { return dep.UnmarshalBinary(append(out[LenSchHead:], 0)) },
+		func() error { return schBack.UnmarshalBinary(append(out, 0)) },
+		func() error { return schBack.UnmarshalBinary(out[:LenSchHead+1]) },
+		func() error { _, err := (DePkt{Dtype: DEtypeRaw, Dlen: 3}).MarshalBinary(); return err },
+		func() error { _, err := (SchPkt{Dep: DePkt{Dtype: DEtypeString, Dlen: 1}}).MarshalBinary(); return err },
+	}
+	for i, f := range bad {
+		var pe *ParseError
+		if err := f(); !errors.As(err, &pe) {
+			t.Errorf("bad %d: expected ParseError but got %v", i, err)
+		} else {
+			t.Logf("bad %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ucukertz/pg"
+)
+
+// Go representation of a DE type
+type goType struct {
+	Type  string // Go type of DE value
+	Mk    string // Suffix of MkDeSet* and MkDeRep* helpers
+	Value string // Expression converting dep into Go value
+	Zero  string // Zero value of Go type
+}
+
+var goTypes = map[pg.DEtype]goType{
+	pg.DEtypeRaw:    {"[]byte", "Raw", "dep.DataRaw", "nil"},
+	pg.DEtypeString: {"string", "Str", "string(dep.DataRaw)", `""`},
+	pg.DEtypeBool:   {"bool", "Bool", "dep.Data != 0", "false"},
+	pg.DEtypeEnum:   {"byte", "Enum", "byte(dep.Data)", "0"},
+	pg.DEtypeUint:   {"uint32", "Uint", "dep.Data", "0"},
+	pg.DEtypeBmap1:  {"byte", "Bmap1", "byte(dep.Data)", "0"},
+	pg.DEtypeBmap2:  {"uint16", "Bmap2", "uint16(dep.Data)", "0"},
+	pg.DEtypeBmap4:  {"uint32", "Bmap4", "dep.Data", "0"},
+	pg.DEtypeInt8:   {"int8", "Int8", "int8(dep.Data)", "0"},
+	pg.DEtypeInt16:  {"int16", "Int16", "int16(dep.Data)", "0"},
+	pg.DEtypeInt32:  {"int32", "Int32", "int32(dep.Data)", "0"},
+	pg.DEtypeFloat:  {"float32", "Float", "float32(pg.DepFloatData(dep))", "0"},
+	pg.DEtypeFixed:  {"float64", "Fixed", "pg.DepFloatData(dep)", "0"},
+}
+
+var groupNames = map[pg.DEGroup]string{
+	pg.DegInfo:    "pg.DegInfo",
+	pg.DegSensor:  "pg.DegSensor",
+	pg.DegControl: "pg.DegControl",
+}
+
+var typeNames = map[pg.DEtype]string{
+	pg.DEtypeRaw:    "pg.DEtypeRaw",
+	pg.DEtypeString: "pg.DEtypeString",
+	pg.DEtypeBool:   "pg.DEtypeBool",
+	pg.DEtypeEnum:   "pg.DEtypeEnum",
+	pg.DEtypeUint:   "pg.DEtypeUint",
+	pg.DEtypeBmap1:  "pg.DEtypeBmap1",
+	pg.DEtypeBmap2:  "pg.DEtypeBmap2",
+	pg.DEtypeBmap4:  "pg.DEtypeBmap4",
+	pg.DEtypeInt8:   "pg.DEtypeInt8",
+	pg.DEtypeInt16:  "pg.DEtypeInt16",
+	pg.DEtypeInt32:  "pg.DEtypeInt32",
+	pg.DEtypeFloat:  "pg.DEtypeFloat",
+	pg.DEtypeFixed:  "pg.DEtypeFixed",
+}
+
+var accessNames = map[pg.DEAccess]string{
+	pg.AccessRead:  "pg.AccessRead",
+	pg.AccessWrite: "pg.AccessWrite",
+	pg.AccessRW:    "pg.AccessRW",
+}
+
+type genConst struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+type genDE struct {
+	pg.DEDef
+	Ident    string
+	GroupLit string
+	TypeLit  string
+	Access   string
+	Go       goType
+	Consts   []genConst
+	MkArgs   string // Extra arguments of MkDeSet* and MkDeRep* helpers
+	EnumLit  string
+	BitsLit  string
+	Writable bool
+	Readable bool
+}
+
+type genFile struct {
+	Src     string
+	Pkg     string
+	Product string
+	DE      []genDE
+}
+
+// Convert DE name into exported Go identifier
+func ident(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "DE" + s
+	}
+	return s
+}
+
+// Generate Go source with typed accessors of every DE in prod
+func generate(prod *pg.Product, pkg, src string) ([]byte, error) {
+	f := genFile{Src: src, Pkg: pkg, Product: prod.Name}
+	// Source of every generated package level identifier
+	seen := map[string]string{"checkDEP": "DE check helper", "NewSchema": "schema constructor"}
+	declare := func(id, src string) error {
+		if other, ok := seen[id]; ok {
+			return fmt.Errorf("%s and %s both map to identifier %s", other, src, id)
+		}
+		seen[id] = src
+		return nil
+	}
+	for _, def := range prod.Schema.Defs() {
+		gt, ok := goTypes[def.Dtype]
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported type %s", def.Name, def.Dtype)
+		}
+		d := genDE{
+			DEDef:    def,
+			Ident:    ident(def.Name),
+			GroupLit: groupNames[def.Group],
+			TypeLit:  typeNames[def.Dtype],
+			Access:   accessNames[def.Access],
+			Go:       gt,
+			Writable: def.Access&pg.AccessWrite != 0,
+			Readable: def.Access&pg.AccessRead != 0,
+		}
+		if def.Dtype == pg.DEtypeFixed {
+			d.MkArgs = fmt.Sprintf(", %g, %g", def.Scale, def.Offset)
+		}
+		funcs := []string{"Group", "Id", "Parse"}
+		if d.Writable {
+			funcs = append(funcs, "Set")
+		}
+		if d.Readable {
+			funcs = append(funcs, "Report")
+		}
+		for _, prefix := range funcs {
+			if err := declare(prefix+d.Ident, "DE "+def.Name); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(def.Enum) > 0 {
+			vals := make([]int, 0, len(def.Enum))
+			for v := range def.Enum {
+				vals = append(vals, int(v))
+			}
+			sort.Ints(vals)
+			lits := []string{}
+			for _, v := range vals {
+				label := def.Enum[byte(v)]
+				d.Consts = append(d.Consts, genConst{d.Ident + ident(label), gt.Type, fmt.Sprintf("%d", v)})
+				if err := declare(d.Ident+ident(label), fmt.Sprintf("DE %s enum %q", def.Name, label)); err != nil {
+					return nil, err
+				}
+				lits = append(lits, fmt.Sprintf("%d: %q", v, label))
+			}
+			d.EnumLit = "map[byte]string{" + strings.Join(lits, ", ") + "}"
+		}
+		if len(def.Bits) > 0 {
+			lits := []string{}
+			for i, name := range def.Bits {
+				d.Consts = append(d.Consts, genConst{d.Ident + ident(name), gt.Type, fmt.Sprintf("1 << %d", i)})
+				if err := declare(d.Ident+ident(name), fmt.Sprintf("DE %s bit %q", def.Name, name)); err != nil {
+					return nil, err
+				}
+				lits = append(lits, fmt.Sprintf("%q", name))
+			}
+			d.BitsLit = "[]string{" + strings.Join(lits, ", ") + "}"
+		}
+		f.DE = append(f.DE, d)
+	}
+
+	var buf bytes.Buffer
+	if err := genTmpl.Execute(&buf, f); err != nil {
+		return nil, err
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, buf.Bytes())
+	}
+	return out, nil
+}
+
+var genTmpl = template.Must(template.New("gen").Parse(`// Code generated by pggen from {{.Src}}. DO NOT EDIT.
+
+// Typed DE accessors of {{if .Product}}{{.Product}}{{else}}product{{end}}
+package {{.Pkg}}
+
+import (
+	"fmt"
+
+	"github.com/ucukertz/pg"
+)
+{{range .DE}}
+// {{.Ident}}: {{.Group}}/{{.Id}} {{.Dtype}}{{if .Unit}} [{{.Unit}}]{{end}}
+const (
+	Group{{.Ident}}      = {{.GroupLit}}
+	Id{{.Ident}}    byte = {{.Id}}
+{{- range .Consts}}
+	{{.Name}} {{.Type}} = {{.Value}}
+{{- end}}
+)
+{{end}}
+// Check that dep is DE g/id of type t
+func checkDEP(dep pg.DePkt, g pg.DEGroup, id byte, t pg.DEtype) error {
+	if dep.Group != g || dep.Id != id {
+		return fmt.Errorf("%w: expected %s/%d but got %s/%d", pg.ErrDEUnknown, g, id, dep.Group, dep.Id)
+	}
+	if dep.Dtype != t {
+		return fmt.Errorf("%w: expected type %s but got %s", pg.ErrDEValue, t, dep.Dtype)
+	}
+	return nil
+}
+{{range .DE}}{{$g := .Go}}
+{{- if .Writable}}
+// Make DE set packet of {{.Ident}}
+func Set{{.Ident}}(v {{$g.Type}}) []byte {
+	return pg.MkDeSet{{$g.Mk}}(Group{{.Ident}}, Id{{.Ident}}, v{{.MkArgs}})
+}
+{{end}}
+{{- if .Readable}}
+// Make DE report packet of {{.Ident}}
+func Report{{.Ident}}(v {{$g.Type}}) []byte {
+	return pg.MkDeRep{{$g.Mk}}(Group{{.Ident}}, Id{{.Ident}}, v{{.MkArgs}})
+}
+{{end}}
+// Get {{.Ident}} value from DE packet
+func Parse{{.Ident}}(dep pg.DePkt) ({{$g.Type}}, error) {
+	if err := checkDEP(dep, Group{{.Ident}}, Id{{.Ident}}, {{.TypeLit}}); err != nil {
+		return {{$g.Zero}}, err
+	}
+	return {{$g.Value}}, nil
+}
+{{end}}
+// Create DE schema of {{if .Product}}{{.Product}}{{else}}product{{end}}
+func NewSchema() *pg.Schema {
+	s := pg.NewSchema()
+	defs := []pg.DEDef{
+{{- range .DE}}
+		{Name: {{printf "%q" .Name}}, Group: {{.GroupLit}}, Id: {{.Id}}, Dtype: {{.TypeLit}}, Access: {{.Access}}
+			{{- if .Unit}}, Unit: {{printf "%q" .Unit}}{{end}}
+			{{- if gt .Max .Min}}, Min: {{.Min}}, Max: {{.Max}}{{end}}
+			{{- if .MaxLen}}, MaxLen: {{.MaxLen}}{{end}}
+			{{- if .Scale}}, Scale: {{.Scale}}, Offset: {{.Offset}}{{end}}
+			{{- if .EnumLit}}, Enum: {{.EnumLit}}{{end}}
+			{{- if .BitsLit}}, Bits: {{.BitsLit}}{{end}}},
+{{- end}}
+	}
+	for _, def := range defs {
+		if err := s.Add(def); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+`))
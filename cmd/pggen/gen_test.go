@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ucukertz/pg"
+)
+
+func TestGenerate(t *testing.T) {
+	prod, err := pg.LoadProductFile("../../testdata/fan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(prod, "fan", "fan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile("../../examples/fan/fan_pg.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Error("examples/fan/fan_pg.go is stale, run go generate ./examples/...")
+	}
+	for _, want := range []string{
+		"func SetFanSpeed(v uint32) []byte",
+		"func ParseTemperature(dep pg.DePkt) (float64, error)",
+		"pg.MkDeRepFixed(GroupTemperature, IdTemperature, v, 0.1, -40)",
+		"ModeBreeze byte = 1",
+		"AlarmsDoor     byte = 1 << 2",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("generated code lacks %q", want)
+		}
+	}
+	if bytes.Contains(src, []byte("func SetTemperature")) {
+		t.Error("read-only DE has set function")
+	}
+}
+
+func TestGenerateCollision(t *testing.T) {
+	cases := []struct {
+		entities string
+		want     string
+	}{
+		{`{"name": "Fan speed", "group": "control", "id": 1, "type": "uint"},
+		  {"name": "FanSpeed", "group": "control", "id": 2, "type": "uint"}`,
+			`DE Fan speed and DE FanSpeed both map to identifier GroupFanSpeed`},
+		{`{"name": "Set", "group": "control", "id": 1, "type": "enum", "enum": {"0": "Fan"}},
+		  {"name": "Fan", "group": "control", "id": 2, "type": "bool", "access": "rw"}`,
+			`DE Set enum "Fan" and DE Fan both map to identifier SetFan`},
+		{`{"name": "Alarms", "group": "sensor", "id": 1, "type": "bmap1", "bits": ["door", "Door"]}`,
+			`DE Alarms bit "door" and DE Alarms bit "Door" both map to identifier AlarmsDoor`},
+		{`{"name": "New", "group": "control", "id": 1, "type": "enum", "enum": {"0": "Schema"}}`,
+			`schema constructor and DE New enum "Schema" both map to identifier NewSchema`},
+	}
+	for _, c := range cases {
+		prod, err := pg.LoadProduct(strings.NewReader(`{"product": "P", "entities": [` + c.entities + `]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = generate(prod, "p", "p.json"); err == nil || err.Error() != c.want {
+			t.Errorf("expected %q but got %v", c.want, err)
+		}
+	}
+}
+
+func TestIdent(t *testing.T) {
+	cases := map[string]string{
+		"fan speed":   "FanSpeed",
+		"temp_in-c":   "TempInC",
+		"2nd sensor":  "DE2ndSensor",
+		"AlreadyGood": "AlreadyGood",
+	}
+	for in, want := range cases {
+		if got := ident(in); got != want {
+			t.Errorf("ident(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
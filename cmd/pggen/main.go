@@ -0,0 +1,46 @@
+// Pggen generates typed Go accessors from a pg product definition file
+//
+// Usage:
+//
+//	//go:generate go run github.com/ucukertz/pg/cmd/pggen -in product.json -pkg fan -out fan_pg.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ucukertz/pg"
+)
+
+func main() {
+	in := flag.String("in", "", "product definition file")
+	out := flag.String("out", "", "output Go file, standard output when empty")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of generated code")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "pggen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	if in == "" || pkg == "" {
+		return fmt.Errorf("-in and -pkg are required")
+	}
+	prod, err := pg.LoadProductFile(in)
+	if err != nil {
+		return err
+	}
+	src, err := generate(prod, pkg, filepath.Base(in))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ucukertz/pg"
+)
+
+func runDecode(args []string, stdin io.Reader, stdout io.Writer) error {
+	var f ioFlags
+	fs, err := parseFlags("decode", args, &f)
+	if err != nil {
+		return err
+	}
+	buf, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+	if !f.bin {
+		if buf, err = parseHex(string(buf)); err != nil {
+			return err
+		}
+	}
+
+	d := pg.NewDecoder(bytes.NewReader(buf))
+	n := 0
+	for ; ; n++ {
+		dropped := d.Dropped()
+		p, err := d.Decode()
+		if d.Dropped() > dropped {
+			fmt.Fprintf(stdout, "skipped %d bytes\n", d.Dropped()-dropped)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			fmt.Fprintln(stdout, "truncated frame at end of input")
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if f.json {
+			err = writeJSON(stdout, p)
+		} else {
+			err = writeBreakdown(stdout, n, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		// Report why the whole input is not a frame
+		if _, err := pg.Parse(buf); err != nil {
+			return err
+		}
+		return fmt.Errorf("no frame found")
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, p pg.BasePkt) error {
+	js, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", js)
+	return err
+}
+
+// Write frame header, its canonical text and the content of its data
+func writeBreakdown(out io.Writer, n int, p pg.BasePkt) error {
+	w := &errWriter{w: out}
+	fmt.Fprintf(w, "frame %d: %x\n", n, p.Buf)
+	fmt.Fprintf(w, "  ver %d cmd %s (0x%02x) dlen %d chksum 0x%02x\n", p.Ver, pg.CmdName(p.CommandID), p.CommandID, p.DataLen, p.Buf[len(p.Buf)-1])
+	fmt.Fprintf(w, "  text %s\n", pg.FormatText(p))
+
+	var err error
+	switch p.CommandID {
+	case pg.CmdDESet, pg.CmdDEReport:
+		var depList []pg.DePkt
+		if depList, err = p.GetDEPList(); err == nil {
+			for i, dep := range depList {
+				fmt.Fprintf(w, "  de %d: %s\n", i, dep)
+			}
+		}
+	case pg.CmdSchedule:
+		var sch pg.Sch
+		if sch, err = p.GetSch(); err == nil {
+			switch sch.Kind {
+			case pg.SchKindEraseAll:
+				fmt.Fprintln(w, "  schedule erase all")
+			case pg.SchKindExecReport:
+				fmt.Fprintf(w, "  schedule executed id %d\n", sch.ExecId)
+			case pg.SchKindSet:
+				for i, s := range sch.List {
+					fmt.Fprintf(w, "  schedule %d: %s\n", i, s)
+				}
+			}
+		}
+	case pg.CmdSwUpdate:
+		var swup pg.Swup
+		if swup, err = p.GetSwup(); err == nil {
+			var js []byte
+			js, err = json.Marshal(swup)
+			fmt.Fprintf(w, "  swup %s\n", js)
+		}
+	case pg.CmdHandshake:
+		var msg []byte
+		if msg, err = p.GetHandshake(); err == nil {
+			fmt.Fprintf(w, "  handshake %q\n", msg)
+		}
+	case pg.CmdUplinkInfo:
+		err = writeInfo(w, "uplink info", p.GetUinfo)
+	case pg.CmdNetworkReset:
+		err = writeInfo(w, "network reset", p.GetNetReset)
+	case pg.CmdNetworkStatus:
+		err = writeInfo(w, "network status", p.GetNetStatus)
+	case pg.CmdTimeSync:
+		err = writeInfo(w, "time sync", p.GetTsync)
+	case pg.CmdDEFault:
+		err = writeInfo(w, "DE fault", p.GetDEFault)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "  error %v\n", err)
+	}
+	return w.err
+}
+
+// Writer keeping its first error so lines can be written without checking each of them
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(b []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	var n int
+	n, ew.err = ew.w.Write(b)
+	return n, ew.err
+}
+
+// Write info returned by get, leaving write errors to be kept by w
+func writeInfo[T any](w io.Writer, name string, get func() (T, error)) error {
+	info, err := get()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "  %s %+v\n", name, info)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ucukertz/pg"
+)
+
+func runEncode(args []string, stdin io.Reader, stdout io.Writer) error {
+	var f ioFlags
+	fs, err := parseFlags("encode", args, &f)
+	if err != nil {
+		return err
+	}
+	in, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+	var pkts []pg.BasePkt
+	if f.json {
+		pkts, err = decodeJSON(in)
+	} else {
+		pkts, err = decodeText(in)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pkts {
+		if f.bin {
+			_, err = stdout.Write(p.Buf)
+		} else {
+			_, err = fmt.Fprintf(stdout, "%x\n", p.Buf)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Longest text line of a frame, its data written with up to 4 characters per byte as in quoted strings
+const maxTextLine = 4*(int(pg.LenPktMin)+math.MaxUint16) + 1024
+
+// Parse one packet per text line, skipping empty lines and comments
+func decodeText(in []byte) ([]pg.BasePkt, error) {
+	pkts := []pg.BasePkt{}
+	sc := bufio.NewScanner(bytes.NewReader(in))
+	sc.Buffer(nil, maxTextLine)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "//") {
+			continue
+		}
+		p, err := pg.ParseText(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		pkts = append(pkts, p)
+	}
+	return pkts, sc.Err()
+}
+
+// Parse stream of JSON packets or arrays of JSON packets
+func decodeJSON(in []byte) ([]pg.BasePkt, error) {
+	pkts := []pg.BasePkt{}
+	dec := json.NewDecoder(bytes.NewReader(in))
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return pkts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(raw, []byte("[")) {
+			var list []pg.BasePkt
+			if err = json.Unmarshal(raw, &list); err != nil {
+				return nil, fmt.Errorf("packet %d: %w", len(pkts), err)
+			}
+			pkts = append(pkts, list...)
+			continue
+		}
+		var p pg.BasePkt
+		if err = json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("packet %d: %w", len(pkts), err)
+		}
+		pkts = append(pkts, p)
+	}
+}
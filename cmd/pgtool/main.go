@@ -0,0 +1,136 @@
+// Pgtool decodes, encodes and checksums pg packets
+//
+// Usage:
+//
+//	pgtool decode [-bin] [-json] [-ver N] [file]    print breakdown of every frame in hex or binary input
+//	pgtool encode [-bin] [-json] [-ver N] [file]    encode text lines or JSON packets into frames
+//	pgtool checksum [hex...]                        compute or verify checksum of a frame
+//
+// Input is read from file or standard input when no file is given. Hex input may contain
+// whitespace, 0x prefixes, ':' and ',' between bytes. Text lines use the canonical text format
+// such as "DE_SET control/3 uint=42", empty lines and lines starting with "//" are skipped.
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/ucukertz/pg"
+)
+
+const usage = `usage:
+  pgtool decode [-bin] [-json] [-ver N] [file]
+  pgtool encode [-bin] [-json] [-ver N] [file]
+  pgtool checksum [hex...]
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "pgtool:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand\n%s", usage)
+	}
+	switch args[0] {
+	case "decode":
+		return runDecode(args[1:], stdin, stdout)
+	case "encode":
+		return runEncode(args[1:], stdin, stdout)
+	case "checksum":
+		return runChecksum(args[1:], stdin, stdout)
+	case "help", "-h", "-help", "--help":
+		_, err := io.WriteString(stdout, usage)
+		return err
+	}
+	return fmt.Errorf("unknown subcommand %q\n%s", args[0], usage)
+}
+
+// Flags shared by decode and encode
+type ioFlags struct {
+	bin  bool
+	json bool
+	ver  uint
+}
+
+func parseFlags(name string, args []string, f *ioFlags) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&f.bin, "bin", false, "binary frames instead of hex")
+	fs.BoolVar(&f.json, "json", false, "JSON packets instead of text")
+	fs.UintVar(&f.ver, "ver", uint(pg.PgVer), "default pg version of text format")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if f.ver > 0xff {
+		return nil, fmt.Errorf("-ver %d out of range", f.ver)
+	}
+	if fs.NArg() > 1 {
+		return nil, fmt.Errorf("%s takes at most one file", name)
+	}
+	pg.SetVer(byte(f.ver))
+	return fs, nil
+}
+
+// Read whole input from file named by the only argument or from stdin
+func readInput(fs *flag.FlagSet, stdin io.Reader) ([]byte, error) {
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		return os.ReadFile(fs.Arg(0))
+	}
+	return io.ReadAll(stdin)
+}
+
+// Decode hex bytes separated by whitespace, ':' or ',' with optional 0x prefixes
+func parseHex(s string) ([]byte, error) {
+	var sb strings.Builder
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == ':' || r == ',' }) {
+		if len(field) > 2 && strings.EqualFold(field[:2], "0x") {
+			field = field[2:]
+		}
+		sb.WriteString(field)
+	}
+	buf, err := hex.DecodeString(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("hex input: %w", err)
+	}
+	return buf, nil
+}
+
+func runChecksum(args []string, stdin io.Reader, stdout io.Writer) error {
+	in := strings.Join(args, " ")
+	if len(args) == 0 {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		in = string(b)
+	}
+	buf, err := parseHex(in)
+	if err != nil {
+		return err
+	}
+
+	// Complete frame has its checksum verified, anything else gets one appended
+	if len(buf) >= int(pg.LenPktMin) && buf[pg.IdxHead1] == pg.Head1 && buf[pg.IdxHead2] == pg.Head2 &&
+		len(buf) == int(pg.LenPktMin)+int(binary.BigEndian.Uint16(buf[pg.IdxDlen:])) {
+		last := len(buf) - 1
+		if err := pg.ChksumVerify(buf[:last], buf[last]); err != nil {
+			buf[last] = pg.Chksum(buf[:last])
+			fmt.Fprintf(stdout, "fixed %x\n", buf)
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "chksum 0x%02x ok\n", buf[last])
+		return err
+	}
+	chksum := pg.Chksum(buf)
+	_, err = fmt.Fprintf(stdout, "chksum 0x%02x\nframe %x%02x\n", chksum, buf, chksum)
+	return err
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ucukertz/pg"
+)
+
+func runOut(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &out)
+	return out.String(), err
+}
+
+var errFail = errors.New("write failed")
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errFail }
+
+func mustParse(t *testing.T, s string) pg.BasePkt {
+	t.Helper()
+	buf, err := parseHex(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := pg.Parse(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEncodeDecode(t *testing.T) {
+	text := `
+// Fan speed and morning schedule
+DE_SET control/3 uint=42
+SCHEDULE set [id=1 Mon,Wed 07:30 control/1 bool=true]
+SW_UPDATE chunk idx=3 #6368756e6b
+`
+	hexOut, err := runOut(t, text, "encode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := strings.Fields(hexOut)
+	if len(frames) != 3 || frames[0] != "55aa0005000902030400040000002a44" {
+		t.Fatal(hexOut)
+	}
+
+	out, err := runOut(t, "ff 0x"+strings.Join(frames, ":"), "decode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"skipped 1 bytes",
+		"frame 0: 55aa0005000902030400040000002a44",
+		"ver 0 cmd DESet (0x05) dlen 9 chksum 0x44",
+		"text DE_SET control/3 uint=42",
+		"de 0: group: Control id: 3 dtype: Uint",
+		"schedule 0: id: 1 wdays: Mon,Wed hour: 7 minute: 30",
+		`swup {"scmd":"Chunk","idx":3,"data":"6368756e6b"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("decode output lacks %q:\n%s", want, out)
+		}
+	}
+
+	// JSON output encodes back into the same frames
+	js, err := runOut(t, hexOut, "decode", "-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back, err := runOut(t, js, "encode", "-json"); err != nil || back != hexOut {
+		t.Errorf("expected %s but got %s %v", hexOut, back, err)
+	}
+
+	bin, err := runOut(t, text, "encode", "-bin")
+	if err != nil || !strings.Contains(bin, "\x55\xaa") {
+		t.Errorf("%x %v", bin, err)
+	}
+	if out, err = runOut(t, bin, "decode", "-bin"); err != nil || strings.Count(out, "frame ") != 3 {
+		t.Error(out, err)
+	}
+
+	// Text of large chunk fits in a line
+	chunk := fmt.Sprintf("%x\n", pg.MkSwupChunk(1, bytes.Repeat([]byte{0xab}, 40000)))
+	if out, err = runOut(t, chunk, "decode"); err != nil {
+		t.Fatal(err)
+	}
+	line := out[strings.Index(out, "SW_UPDATE"):]
+	if back, err := runOut(t, line[:strings.IndexByte(line, '\n')], "encode"); err != nil || back != chunk {
+		t.Errorf("large chunk: %.40s %v", back, err)
+	}
+	if err = writeBreakdown(failWriter{}, 0, mustParse(t, frames[0])); !errors.Is(err, errFail) {
+		t.Error(err)
+	}
+
+	if _, err = runOut(t, "DE_SET control/3\n", "encode"); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Error(err)
+	}
+	if _, err = runOut(t, "55aa00050000ff", "decode"); !errors.Is(err, pg.ErrChksum) {
+		t.Error(err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	out, err := runOut(t, "", "checksum", "55", "aa", "00", "05", "00", "00")
+	if err != nil || out != "chksum 0x04\nframe 55aa0005000004\n" {
+		t.Error(out, err)
+	}
+	if out, err = runOut(t, "55aa0005000004", "checksum"); err != nil || out != "chksum 0x04 ok\n" {
+		t.Error(out, err)
+	}
+	if out, err = runOut(t, "", "checksum", "55aa0005000003"); !errors.Is(err, pg.ErrChksum) || out != "fixed 55aa0005000004\n" {
+		t.Error(out, err)
+	}
+	if _, err = runOut(t, "", "checksum", "5"); err == nil {
+		t.Error("odd hex accepted")
+	}
+	if _, err = runOut(t, "", "nope"); err == nil {
+		t.Error("unknown subcommand accepted")
+	}
+}
@@ -1,5 +1,10 @@
 package pg
 
+import (
+	"fmt"
+	"time"
+)
+
 type Error struct {
 	msg string
 }
@@ -8,6 +13,40 @@ func (e *Error) Error() string {
 	return e.msg
 }
 
+// Packet parse failure location wrapping one of the Err* sentinels
+type ParseError struct {
+	Err      error  // Wrapped sentinel such as ErrLenMismatch
+	Cmd      CmdID  // Command ID, only valid when HasCmd is set
+	HasCmd   bool   // Whether failing buffer is a packet whose Command ID is known
+	Offset   int    // Byte offset from start of packet or parsed buffer
+	Field    string // Failing field such as "header 1", "dlen", "DE 2 dlen" or "schedule 1 header"
+	Expected any    // Expected value, nil when not applicable
+	Actual   any    // Actual value, nil when not applicable
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("%v: %s at byte %d", e.Err, e.Field, e.Offset)
+	if e.HasCmd {
+		msg += fmt.Sprintf(" of command 0x%02x", e.Cmd)
+	}
+	if e.Expected != nil || e.Actual != nil {
+		msg += fmt.Sprintf(" expected %s but got %s", fmtParseVal(e.Expected), fmtParseVal(e.Actual))
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Format bytes as hex and other values as is
+func fmtParseVal(v any) string {
+	if b, ok := v.(byte); ok {
+		return fmt.Sprintf("0x%02x", b)
+	}
+	return fmt.Sprint(v)
+}
+
 var (
 	ErrChksum      = &Error{"PG chksum"}
 	ErrCmdId       = &Error{"PG invalid CMD ID"}
@@ -15,6 +54,20 @@ var (
 	ErrLenMismatch = &Error{"PG data length mismatch"}
 	ErrInvalidData = &Error{"PG invalid data"}
 	ErrSchedule    = &Error{"PG schedule"}
+	ErrVersion     = &Error{"PG version mismatch"}
+	ErrTimeout     = &Error{"PG response timeout"}
+	ErrClosed      = &Error{"PG session closed"}
+	ErrSwupReject  = &Error{"PG swup rejected"}
+	ErrSwupBusy    = &Error{"PG swup busy"}
+	ErrSwupNoInfo  = &Error{"PG swup no info"}
+	ErrSwupFailed  = &Error{"PG swup failed"}
+	ErrSwupUnknown = &Error{"PG swup unknown error"}
+	ErrSwupConn    = &Error{"PG swup connection error"}
+	ErrSwupOom     = &Error{"PG swup out of memory"}
+	ErrSchema      = &Error{"PG DE schema"}
+	ErrDEUnknown   = &Error{"PG unknown DE"}
+	ErrDEReadOnly  = &Error{"PG read-only DE"}
+	ErrDEValue     = &Error{"PG DE value out of schema"}
 )
 
 const (
@@ -90,6 +143,11 @@ const (
 	DEtypeBmap1
 	DEtypeBmap2
 	DEtypeBmap4
+	DEtypeInt8
+	DEtypeInt16
+	DEtypeInt32
+	DEtypeFloat // IEEE 754 float32
+	DEtypeFixed // Signed 32-bit raw value with float32 scale and offset
 )
 
 type DEF = byte // Data Entity fault
@@ -104,6 +162,64 @@ const (
 	DefMalformed
 )
 
+type DefKind = byte // DE fault packet kind
+const (
+	DefKindReqAll  DefKind = iota // Request fault report of all DE
+	DefKindNoneAll                // No fault on all DE
+	DefKindAck                    // Fault report acknowledgement
+	DefKindReport                 // Fault report
+)
+
+// DE fault packet info
+type DeFault struct {
+	Kind  DefKind
+	Group DEGroup
+	Id    byte
+	Fault DEF
+}
+
+type SchKind = byte // Schedule packet kind
+const (
+	SchKindEraseAll   SchKind = iota // Erase all schedules request
+	SchKindExecReport                // Schedule execution report
+	SchKindSet                       // Schedule set list
+)
+
+// Schedule packet info
+type Sch struct {
+	Kind   SchKind
+	ExecId byte     // Executed schedule id of execution report
+	List   []SchPkt // Schedules of schedule set list
+}
+
+// Uplink info packet info
+type Uinfo struct {
+	All    bool // Request for all device info
+	IsResp bool
+	Rb     DeviceInfoRB
+	Resp   string
+}
+
+// Network reset packet info
+type NetReset struct {
+	Ack bool
+	Rb  NetRstRB
+}
+
+// Network status packet info
+type NetStatus struct {
+	Ack  bool
+	Data NetstatData
+}
+
+// Time synchronization packet info
+type Tsync struct {
+	NotReady bool
+	IsResp   bool
+	Rb       TimesyncRB
+	Time     time.Time
+}
+
 type SwupScmd = byte // Software update subcommands
 const (
 	SwupScmdInitiate SwupScmd = iota
@@ -159,6 +275,7 @@ const (
 	LenDePktMin byte = 5
 	LenDlen     byte = 2
 	LenTsync    byte = 8
+	LenTsyncReq byte = 1
 	LenSchHead  byte = 4
 
 	LenDeBool  uint16 = 1
@@ -167,6 +284,23 @@ const (
 	LenDeBmap1 uint16 = 1
 	LenDeBmap2 uint16 = 2
 	LenDeBmap4 uint16 = 4
+	LenDeInt8  uint16 = 1
+	LenDeInt16 uint16 = 2
+	LenDeInt32 uint16 = 4
+	LenDeFloat uint16 = 4
+	LenDeFixed uint16 = 12
+)
+
+const (
+	LenDefDataReqAll uint16 = iota
+	LenDefDataNoneAll
+	LenDefDataAck
+	LenDefDataReport
+)
+
+const (
+	LenSchDataEraseAll uint16 = iota
+	LenSchDataExecReport
 )
 
 const (
@@ -213,6 +347,12 @@ const (
 	IdxDEPdata byte = IdxDEPdlen + LenDlen
 )
 
+const (
+	IdxDeFixedRaw    byte = 0
+	IdxDeFixedScale  byte = 4
+	IdxDeFixedOffset byte = 8
+)
+
 const (
 	IdxDefGroup byte = iota
 	IdxDefID
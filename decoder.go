@@ -0,0 +1,163 @@
+package pg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const decoderReadSize = 512
+
+var pktPool = sync.Pool{New: func() any { return &BasePkt{Buf: make([]byte, 0, decoderReadSize)} }}
+
+// Get reusable base packet from pool, return it with ReleasePkt once done
+func AcquirePkt() *BasePkt {
+	return pktPool.Get().(*BasePkt)
+}
+
+// Return base packet to pool
+// p and every slice taken from it must not be used afterwards
+func ReleasePkt(p *BasePkt) {
+	*p = BasePkt{Buf: p.Buf[:0]}
+	pktPool.Put(p)
+}
+
+// Decoder configuration
+type DecoderConfig struct {
+	Ver      byte // Expected pg version
+	CheckVer bool // Reject packets with pg version other than Ver
+}
+
+// Streaming pg packet decoder
+// Skips garbage until the next header and resynchronizes on checksum failures
+type Decoder struct {
+	r       io.Reader
+	cfg     DecoderConfig
+	buf     []byte
+	rerr    error
+	dropped uint64
+}
+
+// Create decoder reading pg packets of any pg version from r
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderConfig(r, DecoderConfig{Ver: PgVer})
+}
+
+// Create decoder reading pg packets from r with cfg as configuration
+func NewDecoderConfig(r io.Reader, cfg DecoderConfig) *Decoder {
+	return &Decoder{r: r, cfg: cfg, buf: make([]byte, 0, decoderReadSize)}
+}
+
+// Decoder configuration
+func (d *Decoder) Config() DecoderConfig {
+	return d.cfg
+}
+
+// Total count of bytes discarded while searching for valid packets
+func (d *Decoder) Dropped() uint64 {
+	return d.dropped
+}
+
+// Discard n bytes from the front of decoder buffer
+func (d *Decoder) drop(n int) {
+	d.dropped += uint64(n)
+	d.consume(n)
+}
+
+// Remove n bytes from the front of decoder buffer
+func (d *Decoder) consume(n int) {
+	d.buf = d.buf[:copy(d.buf, d.buf[n:])]
+}
+
+// Read more bytes from underlying reader into decoder buffer
+func (d *Decoder) fill() error {
+	if d.rerr != nil {
+		return d.rerr
+	}
+	if cap(d.buf)-len(d.buf) < decoderReadSize {
+		grown := make([]byte, len(d.buf), 2*cap(d.buf)+decoderReadSize)
+		copy(grown, d.buf)
+		d.buf = grown
+	}
+	n, err := d.r.Read(d.buf[len(d.buf) : len(d.buf)+decoderReadSize])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.rerr = err
+		if n > 0 {
+			return nil
+		}
+	}
+	return err
+}
+
+// Move decoder buffer to the next header candidate
+func (d *Decoder) sync() {
+	idx := bytes.Index(d.buf, []byte{Head1, Head2})
+	if idx < 0 {
+		// Keep trailing Head1 since Head2 may arrive on the next read
+		idx = len(d.buf)
+		if idx > 0 && d.buf[idx-1] == Head1 {
+			idx--
+		}
+	}
+	if idx > 0 {
+		d.drop(idx)
+	}
+}
+
+// Decode next valid packet from underlying reader
+// Packet with unexpected pg version is consumed and reported as ErrVersion
+// Returns io.EOF when reader is exhausted between packets and
+// io.ErrUnexpectedEOF when it is exhausted in the middle of a packet
+func (d *Decoder) Decode() (BasePkt, error) {
+	pkt := BasePkt{}
+	if err := d.DecodeInto(&pkt); err != nil {
+		return BasePkt{}, err
+	}
+	return pkt, nil
+}
+
+// Decode next valid packet into p reusing storage of p.Buf
+// Pair with AcquirePkt and ReleasePkt to decode without allocating per packet
+func (d *Decoder) DecodeInto(p *BasePkt) error {
+	truncated := false
+	for {
+		d.sync()
+		if len(d.buf) >= int(LenPktMin) {
+			dlen := binary.BigEndian.Uint16(d.buf[IdxDlen : IdxDlen+LenDlen])
+			plen := int(LenPktMin) + int(dlen)
+			if len(d.buf) >= plen {
+				if err := ParseInto(p, d.buf[:plen]); err != nil {
+					// Resync one byte past the bad header
+					d.drop(1)
+					continue
+				}
+				d.consume(plen)
+				if d.cfg.CheckVer && p.Ver != d.cfg.Ver {
+					return fmt.Errorf("%w expected %d but got %d", ErrVersion, d.cfg.Ver, p.Ver)
+				}
+				return nil
+			}
+		}
+
+		err := d.fill()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(d.buf) == 0 {
+			if truncated {
+				return io.ErrUnexpectedEOF
+			}
+			return io.EOF
+		}
+		// Stream ended mid-packet, look for a packet behind the stale header
+		truncated = true
+		d.drop(1)
+	}
+}
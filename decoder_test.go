@@ -0,0 +1,172 @@
+package pg
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoder(t *testing.T) {
+	SetVer(0)
+	var stream []byte
+	stream = append(stream, 0x00, 0x55, 0x13)
+	stream = append(stream, MkHandshake([]byte("msg"))...)
+	bad := MkDeSetUint(DegControl, 3, 42)
+	bad[len(bad)-1]++
+	stream = append(stream, bad...)
+	stream = append(stream, MkDeRepBool(DegSensor, 1, true)...)
+	stream = append(stream, Head1)
+	stream = append(stream, MkNetStatusReport(NetstatOk)...)
+	stream = append(stream, Head1, Head2, 0, CmdDESet)
+
+	d := NewDecoder(iotest.OneByteReader(bytes.NewReader(stream)))
+	want := []CmdID{CmdHandshake, CmdDEReport, CmdNetworkStatus}
+	for i, cid := range want {
+		p, err := d.Decode()
+		if err != nil {
+			t.Fatalf("pkt %d: %s", i, err)
+		}
+		t.Logf("pkt %d: %s", i, p)
+		if p.CommandID != cid {
+			t.Errorf("pkt %d: expected cmd %d but got %d", i, cid, p.CommandID)
+		}
+	}
+	_, err := d.Decode()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected EOF but got %v", err)
+	}
+	expectedDrop := uint64(3 + len(bad) + 1 + 4)
+	if d.Dropped() != expectedDrop {
+		t.Errorf("expected %d dropped bytes but got %d", expectedDrop, d.Dropped())
+	}
+	_, err = d.Decode()
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF but got %v", err)
+	}
+}
+
+func TestDecoderDataOwnership(t *testing.T) {
+	SetVer(0)
+	stream := append(MkDeRepStr(DegInfo, 1, "first"), MkDeRepStr(DegInfo, 2, "second")...)
+	d := NewDecoder(bytes.NewReader(stream))
+	p1, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dep1, err := p1.GetDEP()
+	if err != nil || string(dep1.DataRaw) != "first" {
+		t.Error(err, dep1)
+	}
+	dep2, err := p2.GetDEP()
+	if err != nil || string(dep2.DataRaw) != "second" {
+		t.Error(err, dep2)
+	}
+}
+
+func TestParseOwnership(t *testing.T) {
+	SetVer(0)
+	buf := MkDeRepStr(DegInfo, 1, "first")
+	view, err := ParseView(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned, err := Parse(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &view.Buf[0] != &buf[0] || &view.Data[0] != &buf[IdxData] {
+		t.Error("view does not alias input")
+	}
+	if &owned.Buf[0] == &buf[0] || &owned.Data[0] != &owned.Buf[IdxData] {
+		t.Error("owned packet does not own a single copy")
+	}
+
+	depView, err := ParseDEPView(view.Data)
+	if err != nil || &depView.DataRaw[0] != &view.Data[IdxDEPdata] {
+		t.Error(err, depView)
+	}
+	dep, err := ParseDEP(view.Data)
+	if err != nil || &dep.DataRaw[0] != &dep.Buf[IdxDEPdata] {
+		t.Error(err, dep)
+	}
+
+	copy(buf[int(IdxData)+int(IdxDEPdata):], "xxxxx")
+	if string(depView.DataRaw) != "xxxxx" || string(dep.DataRaw) != "first" || string(owned.Data[IdxDEPdata:]) != "first" {
+		t.Error(string(depView.DataRaw), string(dep.DataRaw), string(owned.Data))
+	}
+
+	p := BasePkt{Buf: make([]byte, 0, 64)}
+	storage := &p.Buf[:1][0]
+	if err = ParseInto(&p, MkDeRepUint(DegSensor, 1, 42)); err != nil || &p.Buf[0] != storage {
+		t.Error(err, p)
+	}
+	if err = ParseInto(&p, buf[:3]); err == nil || p.CommandID != CmdDEReport {
+		t.Error(err, p)
+	}
+}
+
+func TestDecoderDecodeInto(t *testing.T) {
+	SetVer(0)
+	pkt := MkDeRepUint(DegSensor, 1, 42)
+	r := bytes.NewReader(nil)
+	d := NewDecoder(r)
+	p := AcquirePkt()
+	defer ReleasePkt(p)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(pkt)
+		if err := d.DecodeInto(p); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocation but got %g", allocs)
+	}
+	if dep, err := p.GetDEP(); err != nil || dep.Data != 42 {
+		t.Error(err, dep)
+	}
+	if err := d.DecodeInto(p); err != io.EOF {
+		t.Error(err)
+	}
+}
+
+func TestDecoderVersion(t *testing.T) {
+	SetVer(0)
+	var stream bytes.Buffer
+	e1 := NewEncoderVer(&stream, 1)
+	e2 := NewEncoderVer(&stream, 2)
+	if err := e1.Encode(MkUinfoReq(DeviceID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e2.Encode(MkUinfoReq(DeviceID)); err != nil {
+		t.Fatal(err)
+	}
+	p := e1.Create(CmdNetworkStatus)
+	p.AppendOne(NetstatOk)
+	if err := e1.EncodePkt(p); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoderConfig(&stream, DecoderConfig{Ver: 1, CheckVer: true})
+	p1, err := d.Decode()
+	if err != nil || p1.Ver != 1 || p1.CommandID != CmdUplinkInfo {
+		t.Error(err, p1)
+	}
+	_, err = d.Decode()
+	if !errors.Is(err, ErrVersion) {
+		t.Errorf("expected version error but got %v", err)
+	}
+	p3, err := d.Decode()
+	if err != nil || p3.Ver != 1 || p3.CommandID != CmdNetworkStatus {
+		t.Error(err, p3)
+	}
+	if PgVer != 0 {
+		t.Errorf("default version changed to %d", PgVer)
+	}
+}
@@ -0,0 +1,47 @@
+package pg
+
+import (
+	"io"
+)
+
+// pg packet encoder with its own pg version
+type Encoder struct {
+	w   io.Writer
+	Ver byte // pg version stamped on every packet written
+}
+
+// Create encoder writing pg packets to w using default pg version
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderVer(w, PgVer)
+}
+
+// Create encoder writing pg packets to w using ver as pg version
+func NewEncoderVer(w io.Writer, ver byte) *Encoder {
+	return &Encoder{w: w, Ver: ver}
+}
+
+// Create unbuilt packet with cid as Command ID using encoder pg version
+func (e *Encoder) Create(cid CmdID) BuildPkt {
+	return CreateVer(e.Ver, cid)
+}
+
+// Build unbuilt packet with encoder pg version then write it
+func (e *Encoder) EncodePkt(p BuildPkt) error {
+	p.Ver = e.Ver
+	_, err := e.w.Write(p.Build().Buf)
+	return err
+}
+
+// Write built packet such as the output of Mk* helpers
+// Packet is restamped with encoder pg version without modifying buf
+func (e *Encoder) Encode(buf []byte) error {
+	if len(buf) < int(LenPktMin) {
+		return ErrTooShort
+	}
+	if buf[IdxVer] != e.Ver {
+		buf = append([]byte(nil), buf...)
+		SetPktVer(buf, e.Ver)
+	}
+	_, err := e.w.Write(buf)
+	return err
+}
@@ -0,0 +1,4 @@
+// Example of DE accessors generated by pggen
+package fan
+
+//go:generate go run ../../cmd/pggen -in ../../testdata/fan.json -out fan_pg.go
@@ -0,0 +1,215 @@
+// Code generated by pggen from fan.json. DO NOT EDIT.
+
+// Typed DE accessors of Fan
+package fan
+
+import (
+	"fmt"
+
+	"github.com/ucukertz/pg"
+)
+
+// Serial: Info/0 String
+const (
+	GroupSerial      = pg.DegInfo
+	IdSerial    byte = 0
+)
+
+// Temperature: Sensor/1 Fixed [C]
+const (
+	GroupTemperature      = pg.DegSensor
+	IdTemperature    byte = 1
+)
+
+// Humidity: Sensor/2 Float [%]
+const (
+	GroupHumidity      = pg.DegSensor
+	IdHumidity    byte = 2
+)
+
+// Alarms: Sensor/3 Bmap1
+const (
+	GroupAlarms         = pg.DegSensor
+	IdAlarms       byte = 3
+	AlarmsOverheat byte = 1 << 0
+	AlarmsFilter   byte = 1 << 1
+	AlarmsDoor     byte = 1 << 2
+)
+
+// Tilt: Sensor/4 Int16 [deg]
+const (
+	GroupTilt      = pg.DegSensor
+	IdTilt    byte = 4
+)
+
+// Power: Control/1 Bool
+const (
+	GroupPower      = pg.DegControl
+	IdPower    byte = 1
+)
+
+// Mode: Control/2 Enum
+const (
+	GroupMode       = pg.DegControl
+	IdMode     byte = 2
+	ModeAuto   byte = 0
+	ModeBreeze byte = 1
+	ModeSleep  byte = 2
+)
+
+// FanSpeed: Control/7 Uint [rpm]
+const (
+	GroupFanSpeed      = pg.DegControl
+	IdFanSpeed    byte = 7
+)
+
+// Check that dep is DE g/id of type t
+func checkDEP(dep pg.DePkt, g pg.DEGroup, id byte, t pg.DEtype) error {
+	if dep.Group != g || dep.Id != id {
+		return fmt.Errorf("%w: expected %s/%d but got %s/%d", pg.ErrDEUnknown, g, id, dep.Group, dep.Id)
+	}
+	if dep.Dtype != t {
+		return fmt.Errorf("%w: expected type %s but got %s", pg.ErrDEValue, t, dep.Dtype)
+	}
+	return nil
+}
+
+// Make DE report packet of Serial
+func ReportSerial(v string) []byte {
+	return pg.MkDeRepStr(GroupSerial, IdSerial, v)
+}
+
+// Get Serial value from DE packet
+func ParseSerial(dep pg.DePkt) (string, error) {
+	if err := checkDEP(dep, GroupSerial, IdSerial, pg.DEtypeString); err != nil {
+		return "", err
+	}
+	return string(dep.DataRaw), nil
+}
+
+// Make DE report packet of Temperature
+func ReportTemperature(v float64) []byte {
+	return pg.MkDeRepFixed(GroupTemperature, IdTemperature, v, 0.1, -40)
+}
+
+// Get Temperature value from DE packet
+func ParseTemperature(dep pg.DePkt) (float64, error) {
+	if err := checkDEP(dep, GroupTemperature, IdTemperature, pg.DEtypeFixed); err != nil {
+		return 0, err
+	}
+	return pg.DepFloatData(dep), nil
+}
+
+// Make DE report packet of Humidity
+func ReportHumidity(v float32) []byte {
+	return pg.MkDeRepFloat(GroupHumidity, IdHumidity, v)
+}
+
+// Get Humidity value from DE packet
+func ParseHumidity(dep pg.DePkt) (float32, error) {
+	if err := checkDEP(dep, GroupHumidity, IdHumidity, pg.DEtypeFloat); err != nil {
+		return 0, err
+	}
+	return float32(pg.DepFloatData(dep)), nil
+}
+
+// Make DE report packet of Alarms
+func ReportAlarms(v byte) []byte {
+	return pg.MkDeRepBmap1(GroupAlarms, IdAlarms, v)
+}
+
+// Get Alarms value from DE packet
+func ParseAlarms(dep pg.DePkt) (byte, error) {
+	if err := checkDEP(dep, GroupAlarms, IdAlarms, pg.DEtypeBmap1); err != nil {
+		return 0, err
+	}
+	return byte(dep.Data), nil
+}
+
+// Make DE report packet of Tilt
+func ReportTilt(v int16) []byte {
+	return pg.MkDeRepInt16(GroupTilt, IdTilt, v)
+}
+
+// Get Tilt value from DE packet
+func ParseTilt(dep pg.DePkt) (int16, error) {
+	if err := checkDEP(dep, GroupTilt, IdTilt, pg.DEtypeInt16); err != nil {
+		return 0, err
+	}
+	return int16(dep.Data), nil
+}
+
+// Make DE set packet of Power
+func SetPower(v bool) []byte {
+	return pg.MkDeSetBool(GroupPower, IdPower, v)
+}
+
+// Make DE report packet of Power
+func ReportPower(v bool) []byte {
+	return pg.MkDeRepBool(GroupPower, IdPower, v)
+}
+
+// Get Power value from DE packet
+func ParsePower(dep pg.DePkt) (bool, error) {
+	if err := checkDEP(dep, GroupPower, IdPower, pg.DEtypeBool); err != nil {
+		return false, err
+	}
+	return dep.Data != 0, nil
+}
+
+// Make DE set packet of Mode
+func SetMode(v byte) []byte {
+	return pg.MkDeSetEnum(GroupMode, IdMode, v)
+}
+
+// Make DE report packet of Mode
+func ReportMode(v byte) []byte {
+	return pg.MkDeRepEnum(GroupMode, IdMode, v)
+}
+
+// Get Mode value from DE packet
+func ParseMode(dep pg.DePkt) (byte, error) {
+	if err := checkDEP(dep, GroupMode, IdMode, pg.DEtypeEnum); err != nil {
+		return 0, err
+	}
+	return byte(dep.Data), nil
+}
+
+// Make DE set packet of FanSpeed
+func SetFanSpeed(v uint32) []byte {
+	return pg.MkDeSetUint(GroupFanSpeed, IdFanSpeed, v)
+}
+
+// Make DE report packet of FanSpeed
+func ReportFanSpeed(v uint32) []byte {
+	return pg.MkDeRepUint(GroupFanSpeed, IdFanSpeed, v)
+}
+
+// Get FanSpeed value from DE packet
+func ParseFanSpeed(dep pg.DePkt) (uint32, error) {
+	if err := checkDEP(dep, GroupFanSpeed, IdFanSpeed, pg.DEtypeUint); err != nil {
+		return 0, err
+	}
+	return dep.Data, nil
+}
+
+// Create DE schema of Fan
+func NewSchema() *pg.Schema {
+	s := pg.NewSchema()
+	defs := []pg.DEDef{
+		{Name: "Serial", Group: pg.DegInfo, Id: 0, Dtype: pg.DEtypeString, Access: pg.AccessRead, MaxLen: 16},
+		{Name: "Temperature", Group: pg.DegSensor, Id: 1, Dtype: pg.DEtypeFixed, Access: pg.AccessRead, Unit: "C", Min: -40, Max: 125, Scale: 0.1, Offset: -40},
+		{Name: "Humidity", Group: pg.DegSensor, Id: 2, Dtype: pg.DEtypeFloat, Access: pg.AccessRead, Unit: "%", Min: 0, Max: 100},
+		{Name: "Alarms", Group: pg.DegSensor, Id: 3, Dtype: pg.DEtypeBmap1, Access: pg.AccessRead, Bits: []string{"Overheat", "Filter", "Door"}},
+		{Name: "Tilt", Group: pg.DegSensor, Id: 4, Dtype: pg.DEtypeInt16, Access: pg.AccessRead, Unit: "deg", Min: -90, Max: 90},
+		{Name: "Power", Group: pg.DegControl, Id: 1, Dtype: pg.DEtypeBool, Access: pg.AccessRW},
+		{Name: "Mode", Group: pg.DegControl, Id: 2, Dtype: pg.DEtypeEnum, Access: pg.AccessRW, Enum: map[byte]string{0: "Auto", 1: "Breeze", 2: "Sleep"}},
+		{Name: "FanSpeed", Group: pg.DegControl, Id: 7, Dtype: pg.DEtypeUint, Access: pg.AccessRW, Unit: "rpm", Min: 0, Max: 3000},
+	}
+	for _, def := range defs {
+		if err := s.Add(def); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
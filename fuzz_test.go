@@ -0,0 +1,156 @@
+package pg
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Seed packets covering every command
+func fuzzSeedPkts() [][]byte {
+	sch := []SchPkt{
+		{Id: 1, Weekdays: 0b0111110, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1}},
+		{Id: 2, Weekdays: 0b1000001, Hour: 9, Dep: DePkt{Group: DegControl, Id: 2, Dtype: DEtypeString, Dlen: 2, DataRaw: []byte("hi")}},
+	}
+	return [][]byte{
+		MkHandshake([]byte("pg")),
+		MkUinfoReqAll(),
+		MkUinfoResp(DeviceName, "fan"),
+		MkNetResetReq(NetAP),
+		MkNetStatusReport(NetstatOk),
+		MkTsyncResp(TsyncUTC, time.Date(2024, 3, 4, 7, 30, 0, 0, time.UTC)),
+		MkDeSetUint(DegControl, 1, 42),
+		MkDeRepStr(DegInfo, 0, "SN1234"),
+		MkDeRepFixed(DegSensor, 1, -12.3, 0.1, -40),
+		MkDeFaultRep(DegSensor, 1, DefBroken),
+		MkSchSet(sch),
+		MkSchExecReport(1),
+		MkSwupSrep(SrepAccept),
+		MkSwupSetChunksz(256),
+		MkSwupStatus(true, true, SwupOk),
+		MkSwupChunkReq(3),
+		MkSwupChunk(3, []byte("chunk")),
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, buf := range fuzzSeedPkts() {
+		f.Add(buf)
+	}
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		p, err := Parse(buf)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(p.Buf, buf) || len(p.Data) != int(p.DataLen) {
+			t.Fatalf("parsed %s from %x", p, buf)
+		}
+		_ = p.String()
+		p.GetDEPList()
+		p.GetSch()
+		p.GetSwup()
+		p.GetHandshake()
+		p.GetUinfo()
+		p.GetNetReset()
+		p.GetNetStatus()
+		p.GetTsync()
+		p.GetDEFault()
+	})
+}
+
+func FuzzParseDEP(f *testing.F) {
+	f.Add(MkDESList([]DePkt{{Group: DegControl, Id: 1, Dtype: DEtypeUint, Dlen: LenDeUint, Data: 42}})[IdxData:])
+	f.Add([]byte{byte(DegInfo), 0, byte(DEtypeString), 0, 2, 'h', 'i'})
+	f.Add(append([]byte{byte(DegSensor), 1, byte(DEtypeFixed), 0, byte(LenDeFixed)}, mustFixed(20, 0.1, -40)...))
+	f.Add([]byte{byte(DegControl), 1, byte(DEtypeRaw), 1, 0})
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		dep, err := ParseDEP(buf)
+		if err != nil {
+			return
+		}
+		if len(dep.DataRaw) != int(dep.Dlen) || !bytes.Equal(dep.Buf, buf[:len(dep.Buf)]) {
+			t.Fatalf("parsed %s from %x", dep, buf)
+		}
+		_ = dep.String()
+		DepIntData(dep)
+		DepFloatData(dep)
+		DepFixedPoint(dep)
+	})
+}
+
+func FuzzGetSchList(f *testing.F) {
+	for _, buf := range fuzzSeedPkts() {
+		if p, _ := Parse(buf); p.CommandID == CmdSchedule {
+			f.Add(p.Data)
+		}
+	}
+	f.Add([]byte{})
+	f.Add([]byte{2, 1, 2, 3, 4})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 0xffff {
+			return
+		}
+		p := BasePkt{CommandID: CmdSchedule, DataLen: uint16(len(data)), Data: data}
+		schList, err := p.GetSchList()
+		if err != nil {
+			return
+		}
+		// Every byte after the schedule count belongs to exactly one schedule
+		n := 1
+		for _, sch := range schList {
+			n += int(LenSchHead) + len(sch.Dep.Buf)
+		}
+		if n != len(data) || len(schList) != int(data[0]) {
+			t.Fatalf("%d schedules spanning %d bytes from %x", len(schList), n, data)
+		}
+	})
+}
+
+func FuzzGetSwup(f *testing.F) {
+	for _, buf := range fuzzSeedPkts() {
+		if p, _ := Parse(buf); p.CommandID == CmdSwUpdate {
+			f.Add(p.Data)
+		}
+	}
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 0xffff {
+			return
+		}
+		p := BasePkt{CommandID: CmdSwUpdate, DataLen: uint16(len(data)), Data: data}
+		swup, err := p.GetSwup()
+		if err != nil {
+			return
+		}
+		if swup.Scmd == SwupScmdChunk && int(swup.Chunk.Size) != len(data)-int(IdxSwupChunkData) {
+			t.Fatalf("chunk size %d from %x", swup.Chunk.Size, data)
+		}
+	})
+}
+
+func TestParseBounds(t *testing.T) {
+	SetVer(0)
+	b := Create(CmdDEReport)
+	b.Append(make([]byte, 0xffff))
+	p, err := Parse(b.Build().Buf)
+	if err != nil || len(p.Data) != 0xffff {
+		t.Error(err, len(p.Data))
+	}
+
+	short := []DePkt{
+		{Dtype: DEtypeUint, Dlen: LenDeUint, DataRaw: []byte{1, 2}},
+		{Dtype: DEtypeBmap2, Dlen: LenDeBmap2},
+		{Dtype: DEtypeFixed, Dlen: LenDeFixed, DataRaw: []byte{0, 0, 1}},
+	}
+	for _, dep := range short {
+		if v := DepFixedData(dep); v != 0 {
+			t.Errorf("%s: expected 0 but got %d", dep, v)
+		}
+	}
+
+	p = BasePkt{CommandID: CmdSwUpdate, DataLen: LenSwupDataChunkReq}
+	if _, err = p.GetSwup(); !errors.Is(err, ErrLenMismatch) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,537 @@
+package pg
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	cmdNames      = []string{"Handshake", "UplinkInfo", "NetworkReset", "NetworkStatus", "TimeSync", "DESet", "DEReport", "DEFault", "Schedule", "SwUpdate"}
+	swupScmdNames = []string{"Initiate", "Srep", "Chunksz", "Status", "ChunkReq", "Chunk"}
+	swupSrepNames = []string{"Accept", "Reject", "NoInfo", "Busy"}
+	swupErrNames  = []string{"Ok", "Unknown", "Conn", "Oom"}
+)
+
+// Name of v from names, values without name are written in hex
+func byteName(names []string, v byte) string {
+	if int(v) < len(names) {
+		return names[v]
+	}
+	return fmt.Sprintf("0x%02x", v)
+}
+
+// Parse name from names ignoring case, or hex value such as 0x0a
+func parseByteName(names []string, s string, what string) (byte, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return byte(i), nil
+		}
+	}
+	if len(s) > 2 && strings.EqualFold(s[:2], "0x") {
+		if v, err := strconv.ParseUint(s[2:], 16, 8); err == nil {
+			return byte(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s %q", ErrInvalidData, what, s)
+}
+
+// Name of Command ID, unknown Command IDs are written in hex
+func CmdName(cid CmdID) string {
+	return byteName(cmdNames, cid)
+}
+
+// Parse Command ID name as written by CmdName, ignoring case
+func ParseCmdID(s string) (CmdID, error) {
+	return parseByteName(cmdNames, s, "command")
+}
+
+// Name of DE group, unknown groups are written in hex
+func groupName(g DEGroup) string {
+	if g > DegControl {
+		return fmt.Sprintf("0x%02x", byte(g))
+	}
+	return g.String()
+}
+
+// Parse DE group name as written by groupName
+func parseGroupName(s string) (DEGroup, error) {
+	if g, err := ParseDEGroup(s); err == nil {
+		return g, nil
+	}
+	v, err := parseByteName(nil, s, "DE group")
+	return DEGroup(v), err
+}
+
+// Name of DE type, unknown types are written in hex
+func typeName(t DEtype) string {
+	if t > DEtypeFixed {
+		return fmt.Sprintf("0x%02x", byte(t))
+	}
+	return t.String()
+}
+
+// Parse DE type name as written by typeName
+func parseTypeName(s string) (DEtype, error) {
+	if t, err := ParseDEtype(s); err == nil {
+		return t, nil
+	}
+	v, err := parseByteName(nil, s, "DE type")
+	return DEtype(v), err
+}
+
+// Byte slice written as hex string in JSON
+type hexBytes []byte
+
+func (h hexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h)), nil
+}
+
+func (h *hexBytes) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	*h = b
+	return nil
+}
+
+type depJSON struct {
+	Group  string          `json:"group"`
+	Id     byte            `json:"id"`
+	Type   string          `json:"type"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Scale  *float32        `json:"scale,omitempty"`
+	Offset *float32        `json:"offset,omitempty"`
+	Raw    hexBytes        `json:"raw,omitempty"` // Data bytes of DE whose value can not be written exactly
+}
+
+// Write DE as group, id and type names with its typed value
+// Bitmaps are written as bit arrays and DE that would not round-trip as data bytes
+func (p DePkt) MarshalJSON() ([]byte, error) {
+	if err := p.checkDlen(); err != nil {
+		return nil, err
+	}
+	j := depJSON{Group: groupName(p.Group), Id: p.Id, Type: typeName(p.Dtype)}
+	var err error
+	j.Value, err = depValueJSON(p)
+	if p.Dtype == DEtypeFixed {
+		_, scale, offset := DepFixedPoint(p)
+		j.Scale, j.Offset = &scale, &offset
+	}
+
+	wire := AppendDEP(nil, p)
+	if err == nil {
+		var back DePkt
+		back, err = j.dep()
+		if err == nil && !bytes.Equal(AppendDEP(nil, back), wire) {
+			err = ErrInvalidData
+		}
+	}
+	if err != nil {
+		j.Value, j.Scale, j.Offset = nil, nil, nil
+		j.Raw = wire[LenDePktMin:]
+	}
+	return json.Marshal(j)
+}
+
+func (p *DePkt) UnmarshalJSON(b []byte) error {
+	var j depJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	dep, err := j.dep()
+	if err != nil {
+		return err
+	}
+	*p = dep
+	return nil
+}
+
+// Typed JSON value of DE
+func depValueJSON(p DePkt) (json.RawMessage, error) {
+	switch p.Dtype {
+	case DEtypeRaw:
+		return json.Marshal(hexBytes(p.DataRaw[:p.Dlen]))
+	case DEtypeString:
+		if !utf8.Valid(p.DataRaw[:p.Dlen]) {
+			return nil, ErrInvalidData
+		}
+		return json.Marshal(string(p.DataRaw[:p.Dlen]))
+	case DEtypeBool:
+		return json.Marshal(p.Data != 0)
+	case DEtypeEnum, DEtypeUint:
+		return json.Marshal(p.Data)
+	case DEtypeInt8, DEtypeInt16, DEtypeInt32:
+		return json.Marshal(DepIntData(p))
+	case DEtypeFloat:
+		f := math.Float32frombits(p.Data)
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return nil, ErrInvalidData
+		}
+		return json.RawMessage(strconv.FormatFloat(float64(f), 'g', -1, 32)), nil
+	case DEtypeFixed:
+		if len(p.DataRaw) < int(LenDeFixed) {
+			return nil, ErrTooShort
+		}
+		return json.RawMessage(strconv.FormatFloat(DepFloatData(p), 'g', -1, 64)), nil
+	case DEtypeBmap1, DEtypeBmap2, DEtypeBmap4:
+		bits := make([]bool, BmapWidth(p.Dtype))
+		for i := range bits {
+			bits[i] = p.Data&(1<<i) != 0
+		}
+		return json.Marshal(bits)
+	default:
+		return nil, ErrInvalidData
+	}
+}
+
+// Convert JSON DE back into DE packet as parsed from wire
+func (j depJSON) dep() (DePkt, error) {
+	g, err := parseGroupName(j.Group)
+	if err != nil {
+		return DePkt{}, err
+	}
+	t, err := parseTypeName(j.Type)
+	if err != nil {
+		return DePkt{}, err
+	}
+	dep := DePkt{Group: g, Id: j.Id, Dtype: t}
+
+	if j.Value == nil {
+		dep.DataRaw = j.Raw
+		dep.Dlen = uint16(len(j.Raw))
+		dep.Data = DepFixedData(dep)
+	} else if err = j.setValue(&dep); err != nil {
+		if !errors.Is(err, ErrInvalidData) {
+			err = fmt.Errorf("%w: %s value %s: %w", ErrInvalidData, t, j.Value, err)
+		}
+		return DePkt{}, err
+	}
+	return ParseDEP(AppendDEP(nil, dep))
+}
+
+// Set DE data from typed JSON value
+func (j depJSON) setValue(dep *DePkt) error {
+	switch dep.Dtype {
+	case DEtypeRaw:
+		var raw hexBytes
+		if err := json.Unmarshal(j.Value, &raw); err != nil {
+			return err
+		}
+		dep.DataRaw = raw
+	case DEtypeString:
+		var str string
+		if err := json.Unmarshal(j.Value, &str); err != nil {
+			return err
+		}
+		dep.DataRaw = []byte(str)
+	case DEtypeBool:
+		var v bool
+		if err := json.Unmarshal(j.Value, &v); err != nil {
+			return err
+		}
+		dep.Data = boolData(v)
+	case DEtypeEnum, DEtypeUint:
+		return json.Unmarshal(j.Value, &dep.Data)
+	case DEtypeInt8, DEtypeInt16, DEtypeInt32:
+		var v int32
+		if err := json.Unmarshal(j.Value, &v); err != nil {
+			return err
+		}
+		dep.Data = uint32(v)
+	case DEtypeFloat:
+		var v float32
+		if err := json.Unmarshal(j.Value, &v); err != nil {
+			return err
+		}
+		dep.Data = math.Float32bits(v)
+	case DEtypeFixed:
+		var v float64
+		if err := json.Unmarshal(j.Value, &v); err != nil {
+			return err
+		}
+		if j.Scale == nil {
+			return fmt.Errorf("missing scale")
+		}
+		offset := float32(0)
+		if j.Offset != nil {
+			offset = *j.Offset
+		}
+		var err error
+		if dep.DataRaw, err = FixedToBslice(v, *j.Scale, offset); err != nil {
+			return err
+		}
+	case DEtypeBmap1, DEtypeBmap2, DEtypeBmap4:
+		var bits []bool
+		if err := json.Unmarshal(j.Value, &bits); err != nil {
+			return err
+		}
+		if len(bits) > BmapWidth(dep.Dtype) {
+			return fmt.Errorf("%d bits", len(bits))
+		}
+		for i, bit := range bits {
+			if bit {
+				dep.Data |= 1 << i
+			}
+		}
+	}
+	dep.Dlen = EnforceDElen(dep.Dtype, uint16(len(dep.DataRaw)))
+	return nil
+}
+
+// Write weekdays as array of names, set bit 7 is written as "Bit7"
+func (w Weekdays) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for _, d := range w.Days() {
+		names = append(names, weekdayNames[d])
+	}
+	if w&WeekdaysReserved != 0 {
+		names = append(names, "Bit7")
+	}
+	return json.Marshal(names)
+}
+
+// Read weekdays from array of names or ranges, or from a single string as accepted by ParseWeekdays
+func (w *Weekdays) UnmarshalJSON(b []byte) error {
+	var items []string
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		items = []string{str}
+	} else if err = json.Unmarshal(b, &items); err != nil {
+		return err
+	}
+	var days Weekdays
+	for _, item := range items {
+		if strings.EqualFold(item, "Bit7") {
+			days |= WeekdaysReserved
+			continue
+		}
+		d, err := ParseWeekdays(item)
+		if err != nil {
+			return err
+		}
+		days |= d
+	}
+	*w = days
+	return nil
+}
+
+type schJSON struct {
+	Id       byte     `json:"id"`
+	Weekdays Weekdays `json:"weekdays"`
+	Hour     byte     `json:"hour"`
+	Minute   byte     `json:"minute"`
+	DE       DePkt    `json:"de"`
+}
+
+// Write schedule with weekday names and its DE
+func (p SchPkt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(schJSON{p.Id, p.Wdays(), p.Hour, p.Minute, p.Dep})
+}
+
+func (p *SchPkt) UnmarshalJSON(b []byte) error {
+	var j schJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*p = SchPkt{Id: j.Id, Weekdays: byte(j.Weekdays), Hour: j.Hour, Minute: j.Minute, Dep: j.DE}
+	return nil
+}
+
+type swupJSON struct {
+	Scmd    string   `json:"scmd"`
+	Srep    string   `json:"srep,omitempty"`
+	Finish  *bool    `json:"finish,omitempty"`
+	Success *bool    `json:"success,omitempty"`
+	Err     string   `json:"err,omitempty"`
+	Size    *uint16  `json:"size,omitempty"`
+	Idx     *uint32  `json:"idx,omitempty"`
+	Data    hexBytes `json:"data,omitempty"`
+}
+
+// Write software update info with subcommand, simple reply and error names
+// Only fields used by the subcommand are written
+func (s Swup) MarshalJSON() ([]byte, error) {
+	j := swupJSON{Scmd: byteName(swupScmdNames, s.Scmd)}
+	switch s.Scmd {
+	case SwupScmdSrep:
+		j.Srep = byteName(swupSrepNames, s.Srep)
+	case SwupScmdChunksz:
+		j.Size = &s.Chunk.Size
+	case SwupScmdStatus:
+		j.Finish, j.Success = &s.Status.Finish, &s.Status.Success
+		j.Err = byteName(swupErrNames, s.Status.Err)
+	case SwupScmdChunkReq:
+		j.Idx = &s.Chunk.Idx
+	case SwupScmdChunk:
+		j.Idx = &s.Chunk.Idx
+		j.Data = s.Chunk.Data
+	}
+	return json.Marshal(j)
+}
+
+func (s *Swup) UnmarshalJSON(b []byte) error {
+	var j swupJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	scmd, err := parseByteName(swupScmdNames, j.Scmd, "swup subcommand")
+	if err != nil {
+		return err
+	}
+	swup := Swup{Scmd: scmd}
+	switch scmd {
+	case SwupScmdInitiate:
+	case SwupScmdSrep:
+		swup.Srep, err = parseByteName(swupSrepNames, j.Srep, "swup simple reply")
+	case SwupScmdChunksz:
+		if j.Size != nil {
+			swup.Chunk.Size = *j.Size
+		}
+	case SwupScmdStatus:
+		swup.Status.Finish = j.Finish != nil && *j.Finish
+		swup.Status.Success = j.Success != nil && *j.Success
+		if j.Err != "" {
+			swup.Status.Err, err = parseByteName(swupErrNames, j.Err, "swup error")
+		}
+	case SwupScmdChunkReq, SwupScmdChunk:
+		if j.Idx != nil {
+			swup.Chunk.Idx = *j.Idx
+		}
+		if scmd == SwupScmdChunk {
+			swup.Chunk.Data = j.Data
+			swup.Chunk.Size = uint16(len(j.Data))
+		}
+	default:
+		err = fmt.Errorf("%w: swup subcommand %q", ErrInvalidData, j.Scmd)
+	}
+	if err != nil {
+		return err
+	}
+	*s = swup
+	return nil
+}
+
+// Append software update packet data of s to dst
+func appendSwupData(dst []byte, s Swup) ([]byte, error) {
+	var pkt []byte
+	switch s.Scmd {
+	case SwupScmdInitiate:
+		pkt = MkSwupInitiate()
+	case SwupScmdSrep:
+		pkt = MkSwupSrep(s.Srep)
+	case SwupScmdChunksz:
+		pkt = MkSwupSetChunksz(s.Chunk.Size)
+	case SwupScmdStatus:
+		pkt = MkSwupStatus(s.Status.Finish, s.Status.Success, s.Status.Err)
+	case SwupScmdChunkReq:
+		pkt = MkSwupChunkReq(s.Chunk.Idx)
+	case SwupScmdChunk:
+		if len(s.Chunk.Data) == 0 {
+			return nil, fmt.Errorf("%w: empty swup chunk", ErrInvalidData)
+		}
+		pkt = MkSwupChunk(s.Chunk.Idx, s.Chunk.Data)
+	default:
+		return nil, fmt.Errorf("%w: swup subcommand 0x%x", ErrInvalidData, s.Scmd)
+	}
+	return append(dst, pktData(pkt)...), nil
+}
+
+// Data of packet built by Mk* helpers
+func pktData(buf []byte) []byte {
+	return buf[IdxData : len(buf)-1]
+}
+
+type pktJSON struct {
+	Ver       byte     `json:"ver"`
+	Cmd       string   `json:"cmd"`
+	DE        []DePkt  `json:"de,omitempty"`
+	Schedules []SchPkt `json:"schedules,omitempty"`
+	ExecId    *byte    `json:"exec_id,omitempty"`
+	Swup      *Swup    `json:"swup,omitempty"`
+	Data      hexBytes `json:"data,omitempty"` // Data of packet without typed form
+}
+
+// Packet data encoded by typed fields or by Data
+func (j pktJSON) data(cid CmdID) ([]byte, error) {
+	switch {
+	case j.DE != nil:
+		data := []byte{}
+		for _, dep := range j.DE {
+			data = AppendDEP(data, dep)
+		}
+		return data, nil
+	case j.Schedules != nil:
+		pkt, err := AppendSchSet(nil, j.Schedules)
+		if err != nil {
+			return nil, err
+		}
+		return pktData(pkt), nil
+	case j.ExecId != nil:
+		return []byte{*j.ExecId}, nil
+	case j.Swup != nil:
+		return appendSwupData(nil, *j.Swup)
+	default:
+		return j.Data, nil
+	}
+}
+
+// Write packet with command name and typed DE, schedule or software update info
+// Packets whose typed form would not rebuild identical data are written with hex data
+func (p BasePkt) MarshalJSON() ([]byte, error) {
+	j := pktJSON{Ver: p.Ver, Cmd: CmdName(p.CommandID)}
+	switch p.CommandID {
+	case CmdDESet, CmdDEReport:
+		if depList, err := p.GetDEPList(); err == nil && len(depList) > 0 {
+			j.DE = depList
+		}
+	case CmdSchedule:
+		if sch, err := p.GetSch(); err == nil {
+			switch sch.Kind {
+			case SchKindSet:
+				j.Schedules = sch.List
+			case SchKindExecReport:
+				j.ExecId = &sch.ExecId
+			}
+		}
+	case CmdSwUpdate:
+		if swup, err := p.GetSwup(); err == nil {
+			j.Swup = &swup
+		}
+	}
+	if data, err := j.data(p.CommandID); err != nil || !bytes.Equal(data, p.Data) {
+		j = pktJSON{Ver: p.Ver, Cmd: j.Cmd}
+	}
+	if j.DE == nil && j.Schedules == nil && j.ExecId == nil && j.Swup == nil {
+		j.Data = p.Data
+	}
+	return json.Marshal(j)
+}
+
+func (p *BasePkt) UnmarshalJSON(b []byte) error {
+	var j pktJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	cid, err := ParseCmdID(j.Cmd)
+	if err != nil {
+		return err
+	}
+	data, err := j.data(cid)
+	if err != nil {
+		return err
+	}
+	if len(data) > math.MaxUint16 {
+		return fmt.Errorf("%w: %d data bytes", ErrInvalidData, len(data))
+	}
+	buf, start := AppendPktStartVer(nil, j.Ver, cid)
+	buf = AppendPktEnd(append(buf, data...), start)
+	return ParseInto(p, buf)
+}
@@ -0,0 +1,110 @@
+package pg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	SetVer(2)
+	defer SetVer(0)
+	bufs := fuzzSeedPkts()
+	bufs = append(bufs,
+		MkDESList([]DePkt{
+			{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 2},
+			{Group: DegControl, Id: 2, Dtype: DEtypeUint, Dlen: 2, DataRaw: []byte{1, 2}},
+			{Group: DegControl, Id: 3, Dtype: DEtypeBmap2, Data: 0x8001},
+			{Group: DegControl, Id: 4, Dtype: DEtypeInt16, Data: 0xff00},
+			{Group: DegControl, Id: 5, Dtype: DEtypeFloat, Data: 0x3fc00000},
+			{Group: DegControl, Id: 6, Dtype: DEtypeFloat, Data: 0x7fc00000},
+			{Group: 7, Id: 7, Dtype: DEtypeString, Dlen: 2, DataRaw: []byte{0xff, 0xfe}},
+			{Group: DegControl, Id: 8, Dtype: DEtypeRaw, Dlen: 3, DataRaw: []byte{1, 2, 3}},
+			{Group: DegControl, Id: 9, Dtype: 0x20, Dlen: 3, DataRaw: []byte{1, 2, 3}},
+		}),
+		MkSchSet([]SchPkt{{Id: 1, Weekdays: byte(WeekdaysReserved | 1), Dep: DePkt{Group: DegControl, Dtype: DEtypeEnum, Data: 1}}}),
+		MkSchEraseAllReq(),
+		MkSwupInitiate(),
+		MkSwupStatus(false, true, 9),
+		MkDESList(nil),
+	)
+
+	for _, buf := range bufs {
+		p, err := Parse(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		js, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		t.Log(string(js))
+		var back BasePkt
+		if err = json.Unmarshal(js, &back); err != nil {
+			t.Fatalf("%s: %v", js, err)
+		}
+		if !bytes.Equal(back.Buf, buf) {
+			t.Errorf("%s: expected %x but got %x", js, buf, back.Buf)
+		}
+	}
+}
+
+func TestJSONTyped(t *testing.T) {
+	SetVer(0)
+	cases := []struct {
+		buf  []byte
+		want string
+	}{
+		{MkDeSetUint(DegControl, 3, 42), `{"ver":0,"cmd":"DESet","de":[{"group":"Control","id":3,"type":"Uint","value":42}]}`},
+		{MkDeRepBool(DegControl, 1, true), `"type":"Bool","value":true`},
+		{MkDeRepBmap1(DegControl, 1, 0x81), `"value":[true,false,false,false,false,false,false,true]`},
+		{MkDeRepInt8(DegSensor, 1, -5), `"value":-5`},
+		{MkDeRepFixed(DegSensor, 1, -12.3, 0.1, -40), `"value":-12.3,"scale":0.1,"offset":-40`},
+		{MkDeRepStr(DegInfo, 0, "SN1234"), `"value":"SN1234"`},
+		{MkSchSet([]SchPkt{{Id: 1, Weekdays: 0b0001010, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1}}}),
+			`"schedules":[{"id":1,"weekdays":["Mon","Wed"],"hour":7,"minute":30,`},
+		{MkSchExecReport(4), `"cmd":"Schedule","exec_id":4`},
+		{MkSwupStatus(true, false, SwupErrConn), `"swup":{"scmd":"Status","finish":true,"success":false,"err":"Conn"}`},
+		{MkSwupChunk(1, []byte{0xab}), `"swup":{"scmd":"Chunk","idx":1,"data":"ab"}`},
+		{MkHandshake([]byte("pg")), `"cmd":"Handshake","data":"7067"`},
+		{MkDESList([]DePkt{{Group: DegControl, Dtype: DEtypeBool, Data: 2}}), `"raw":"02"`},
+	}
+	for _, c := range cases {
+		p, _ := Parse(c.buf)
+		js, err := json.Marshal(p)
+		if err != nil || !strings.Contains(string(js), c.want) {
+			t.Errorf("expected %s in %s %v", c.want, js, err)
+		}
+	}
+
+	// Weekdays as a single string and names ignoring case
+	var p BasePkt
+	js := `{"cmd":"schedule","schedules":[{"id":1,"weekdays":"Mon-Fri","hour":7,"de":{"group":"control","id":1,"type":"bool","value":true}}]}`
+	if err := json.Unmarshal([]byte(js), &p); err != nil {
+		t.Fatal(err)
+	}
+	schList, err := p.GetSchList()
+	if err != nil || len(schList) != 1 || schList[0].Weekdays != 0b0111110 || schList[0].Dep.Data != 1 {
+		t.Error(schList, err)
+	}
+
+	bad := []string{
+		`{"cmd":"Nope"}`,
+		`{"cmd":"DESet","de":[{"group":"Control","type":"Nope","value":1}]}`,
+		`{"cmd":"DESet","de":[{"group":"Control","type":"Bool","value":1}]}`,
+		`{"cmd":"DESet","de":[{"group":"Control","type":"Bmap1","value":[true,true,true,true,true,true,true,true,true]}]}`,
+		`{"cmd":"DESet","de":[{"group":"Control","type":"Fixed","value":1}]}`,
+		`{"cmd":"DESet","de":[{"group":"Control","type":"Fixed","value":1,"scale":0}]}`,
+		`{"cmd":"Schedule","schedules":[{"weekdays":["Funday"]}]}`,
+		`{"cmd":"SwUpdate","swup":{"scmd":"Chunk"}}`,
+		`{"cmd":"SwUpdate","swup":{"scmd":"0x09"}}`,
+		`{"cmd":"Handshake","data":"zz"}`,
+	}
+	for _, js := range bad {
+		if err := json.Unmarshal([]byte(js), &p); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("%s: expected ErrInvalidData but got %v", js, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package pg
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Packet response writer built on Mk* helpers
+type ResponseWriter struct {
+	enc *Encoder
+	mu  *sync.Mutex
+}
+
+// Create response writer writing packets with e
+func NewResponseWriter(e *Encoder) *ResponseWriter {
+	return &ResponseWriter{enc: e, mu: &sync.Mutex{}}
+}
+
+// Send built packet
+func (w *ResponseWriter) Send(buf []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(buf)
+}
+
+// Send handshake packet
+func (w *ResponseWriter) Handshake(msg []byte) error {
+	return w.Send(MkHandshake(msg))
+}
+
+// Send uplink info response packet
+func (w *ResponseWriter) UinfoResp(rb DeviceInfoRB, resp string) error {
+	return w.Send(MkUinfoResp(rb, resp))
+}
+
+// Send network reset acknowledgement packet
+func (w *ResponseWriter) NetResetACK() error {
+	return w.Send(MkNetResetACK())
+}
+
+// Send network status report packet
+func (w *ResponseWriter) NetStatusReport(r NetstatData) error {
+	return w.Send(MkNetStatusReport(r))
+}
+
+// Send network status report acknowledgement packet
+func (w *ResponseWriter) NetStatusReportACK() error {
+	return w.Send(MkNetStatusReportACK())
+}
+
+// Send time synchronization response packet
+func (w *ResponseWriter) TsyncResp(rb TimesyncRB, tm time.Time) error {
+	return w.Send(MkTsyncResp(rb, tm))
+}
+
+// Send time synchronization not ready packet
+func (w *ResponseWriter) TsyncNotReady() error {
+	return w.Send(MkTsyncNotReady())
+}
+
+// Send DE report packet containing all DE in depList
+func (w *ResponseWriter) DERep(depList ...DePkt) error {
+	return w.Send(MkDERList(depList))
+}
+
+// Send DE fault report packet
+func (w *ResponseWriter) DeFaultRep(g DEGroup, id byte, f DEF) error {
+	return w.Send(MkDeFaultRep(g, id, f))
+}
+
+// Send DE fault acknowledgement packet
+func (w *ResponseWriter) DeFaultAck(g DEGroup, id byte) error {
+	return w.Send(MkDeFaultAck(g, id))
+}
+
+// Send schedule execution report packet
+func (w *ResponseWriter) SchExecReport(schId byte) error {
+	return w.Send(MkSchExecReport(schId))
+}
+
+// Send software update simple reply packet
+func (w *ResponseWriter) SwupSrep(srep SwupSrep) error {
+	return w.Send(MkSwupSrep(srep))
+}
+
+// Packet handler
+type Handler interface {
+	ServePg(w *ResponseWriter, p BasePkt)
+}
+
+// Packet handler function
+type HandlerFunc func(w *ResponseWriter, p BasePkt)
+
+func (f HandlerFunc) ServePg(w *ResponseWriter, p BasePkt) {
+	f(w, p)
+}
+
+// DE set handler function, called once for every DE in a DE set packet
+type DEHandlerFunc func(w *ResponseWriter, p BasePkt, dep DePkt)
+
+// Handler wrapper
+type Middleware func(Handler) Handler
+
+type deKey struct {
+	group DEGroup
+	id    byte
+}
+
+// Command dispatcher routing packets to handlers registered per Command ID
+type Mux struct {
+	mu       sync.RWMutex
+	cmd      map[CmdID]Handler
+	de       map[deKey]DEHandlerFunc
+	fallback Handler
+	mws      []Middleware
+}
+
+// Create empty command dispatcher
+func NewMux() *Mux {
+	return &Mux{cmd: map[CmdID]Handler{}, de: map[deKey]DEHandlerFunc{}}
+}
+
+// Register handler for packets with cid as Command ID
+func (m *Mux) Handle(cid CmdID, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cmd[cid] = h
+}
+
+// Register handler function for packets with cid as Command ID
+func (m *Mux) HandleFunc(cid CmdID, f HandlerFunc) {
+	m.Handle(cid, f)
+}
+
+// Register handler for DE g/id inside DE set packets
+// DE without DE handler are passed to the DE set command handler in a DE set packet of their own
+func (m *Mux) HandleDE(g DEGroup, id byte, f DEHandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.de[deKey{g, id}] = f
+}
+
+// Register handler for packets with no matching handler
+func (m *Mux) HandleFallback(h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallback = h
+}
+
+// Append middlewares wrapping every dispatched packet
+// The first middleware is the outermost one
+func (m *Mux) Use(mws ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mws = append(m.mws, mws...)
+}
+
+// Dispatch packet through middlewares to its handler
+func (m *Mux) ServePg(w *ResponseWriter, p BasePkt) {
+	m.mu.RLock()
+	var h Handler = HandlerFunc(m.route)
+	for i := len(m.mws) - 1; i >= 0; i-- {
+		h = m.mws[i](h)
+	}
+	m.mu.RUnlock()
+	h.ServePg(w, p)
+}
+
+func (m *Mux) route(w *ResponseWriter, p BasePkt) {
+	m.mu.RLock()
+	h := m.cmd[p.CommandID]
+	if h == nil {
+		h = m.fallback
+	}
+	hasDE := len(m.de) > 0
+	m.mu.RUnlock()
+
+	if p.CommandID == CmdDESet && hasDE {
+		depList, err := p.GetDEPList()
+		if err == nil && len(depList) > 0 {
+			// DE set command handler only gets DE that have no DE handler
+			buf, start := AppendPktStartVer(nil, p.Ver, p.CommandID)
+			unhandled := false
+			for _, dep := range depList {
+				m.mu.RLock()
+				f := m.de[deKey{dep.Group, dep.Id}]
+				m.mu.RUnlock()
+				if f == nil {
+					buf = AppendDEP(buf, dep)
+					unhandled = true
+					continue
+				}
+				f(w, p, dep)
+			}
+			if !unhandled {
+				return
+			}
+			if p, err = Parse(AppendPktEnd(buf, start)); err != nil {
+				return
+			}
+		}
+	}
+	if h != nil {
+		h.ServePg(w, p)
+	}
+}
+
+// Decode packets with d and dispatch them with responses written by w until d fails
+// Packets with unexpected pg version reported by d as ErrVersion are dropped
+// Returns nil when d reaches io.EOF
+func (m *Mux) Serve(d *Decoder, w *ResponseWriter) error {
+	for {
+		p, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, ErrVersion) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		m.ServePg(w, p)
+	}
+}
+
+// Serve packets over rw using default pg version
+func (m *Mux) ServeConn(rw io.ReadWriter) error {
+	return m.Serve(NewDecoder(rw), NewResponseWriter(NewEncoder(rw)))
+}
+
+// Middleware calling logf with every packet before handling it
+func LogMiddleware(logf func(format string, args ...any)) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w *ResponseWriter, p BasePkt) {
+			logf("pg recv %s", p)
+			next.ServePg(w, p)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package pg
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMux(t *testing.T) {
+	SetVer(0)
+	var in, out bytes.Buffer
+	in.Write(MkUinfoReq(DeviceName))
+	in.Write(MkNetResetReq(NetAP))
+	in.Write(MkDeSetUint(DegControl, 7, 42))
+	in.Write(MkDESList([]DePkt{
+		{Group: DegControl, Id: 7, Dtype: DEtypeUint, Data: 43},
+		{Group: DegControl, Id: 8, Dtype: DEtypeBool, Data: 1},
+	}))
+	in.Write(MkSwupInitiate())
+
+	var log []string
+	fanSpeed := []uint32{}
+	deSetCalls := 0
+	deSetDEs := []DePkt{}
+	fallbacks := []CmdID{}
+
+	m := NewMux()
+	m.Use(LogMiddleware(func(format string, args ...any) {
+		log = append(log, fmt.Sprintf(format, args...))
+	}))
+	m.HandleFunc(CmdUplinkInfo, func(w *ResponseWriter, p BasePkt) {
+		uinfo, err := p.GetUinfo()
+		if err != nil {
+			t.Error(err)
+		}
+		w.UinfoResp(uinfo.Rb, "genericdevice")
+	})
+	m.HandleFunc(CmdNetworkReset, func(w *ResponseWriter, p BasePkt) {
+		w.NetResetACK()
+	})
+	m.HandleDE(DegControl, 7, func(w *ResponseWriter, p BasePkt, dep DePkt) {
+		fanSpeed = append(fanSpeed, dep.Data)
+		w.DERep(dep)
+	})
+	m.HandleFunc(CmdDESet, func(w *ResponseWriter, p BasePkt) {
+		deSetCalls++
+		depList, err := p.GetDEPList()
+		if err != nil {
+			t.Error(err)
+		}
+		deSetDEs = append(deSetDEs, depList...)
+	})
+	m.HandleFallback(HandlerFunc(func(w *ResponseWriter, p BasePkt) {
+		fallbacks = append(fallbacks, p.CommandID)
+	}))
+
+	if err := m.Serve(NewDecoder(&in), NewResponseWriter(NewEncoder(&out))); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(log) != 5 {
+		t.Errorf("expected 5 log lines but got %d", len(log))
+	}
+	if len(fanSpeed) != 2 || fanSpeed[0] != 42 || fanSpeed[1] != 43 {
+		t.Errorf("unexpected DE handler calls %v", fanSpeed)
+	}
+	if deSetCalls != 1 || len(deSetDEs) != 1 || deSetDEs[0].Id != 8 {
+		t.Errorf("expected 1 DE set handler call with only DE 8 but got %d %v", deSetCalls, deSetDEs)
+	}
+	if len(fallbacks) != 1 || fallbacks[0] != CmdSwUpdate {
+		t.Errorf("unexpected fallback calls %v", fallbacks)
+	}
+
+	d := NewDecoder(&out)
+	want := []CmdID{CmdUplinkInfo, CmdNetworkReset, CmdDEReport, CmdDEReport}
+	for i, cid := range want {
+		p, err := d.Decode()
+		if err != nil || p.CommandID != cid {
+			t.Errorf("response %d: expected cmd %d but got %s %v", i, cid, p, err)
+		}
+	}
+}
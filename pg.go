@@ -3,7 +3,11 @@ package pg
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -39,15 +43,15 @@ type DePkt struct {
 // Schedule packet
 type SchPkt struct {
 	Id       byte
-	Weekdays byte
+	Weekdays byte // Bitmask of weekdays, see Wdays
 	Hour     byte
 	Minute   byte
 	Dep      DePkt
 }
 
-var PgVer byte = 0 // Active pg version
+var PgVer byte = 0 // Default pg version for packets made without explicit version
 
-// Set active pg version
+// Set default pg version
 func SetVer(ver byte) {
 	PgVer = ver
 }
@@ -70,9 +74,24 @@ func ChksumVerify(buf []byte, chksum byte) error {
 	return nil
 }
 
-// Create unbuilt packet with cid as Command ID
+// Create unbuilt packet with cid as Command ID using default pg version
 func Create(cid CmdID) BuildPkt {
-	return BuildPkt{Ver: PgVer, CommandID: cid, DataLen: 0, Data: make([]byte, 0, 32)}
+	return CreateVer(PgVer, cid)
+}
+
+// Create unbuilt packet with cid as Command ID and ver as pg version
+func CreateVer(ver byte, cid CmdID) BuildPkt {
+	return BuildPkt{Ver: ver, CommandID: cid, DataLen: 0, Data: make([]byte, 0, 32)}
+}
+
+// Rewrite pg version of built packet in buf then recalculate its checksum
+func SetPktVer(buf []byte, ver byte) error {
+	if len(buf) < int(LenPktMin) {
+		return ErrTooShort
+	}
+	buf[IdxVer] = ver
+	buf[len(buf)-1] = Chksum(buf[:len(buf)-1])
+	return nil
 }
 
 // Append one byte to unbuilt packet
@@ -101,6 +120,30 @@ func U32ToBslice(v uint32) []byte {
 	return slice[:]
 }
 
+// Convert real value to its fixed-point DE data representation
+func FixedToBslice(v float64, scale float32, offset float32) ([]byte, error) {
+	return AppendFixed(make([]byte, 0, LenDeFixed), v, scale, offset)
+}
+
+// Convert real value to raw value of its fixed-point representation
+// Returns ErrInvalidData for zero or non-finite scale, NaN result and values outside int32 range
+// Raw value returned with error is 0 or saturated to int32 range
+func FixedRaw(v float64, scale float32, offset float32) (int32, error) {
+	if scale == 0 || math.IsNaN(float64(scale)) || math.IsInf(float64(scale), 0) {
+		return 0, fmt.Errorf("%w: fixed-point scale %g", ErrInvalidData, scale)
+	}
+	raw := math.Round((v - float64(offset)) / float64(scale))
+	switch {
+	case math.IsNaN(raw):
+		return 0, fmt.Errorf("%w: fixed-point value %g with offset %g", ErrInvalidData, v, offset)
+	case raw > math.MaxInt32:
+		return math.MaxInt32, fmt.Errorf("%w: fixed-point value %g overflows with scale %g and offset %g", ErrInvalidData, v, scale, offset)
+	case raw < math.MinInt32:
+		return math.MinInt32, fmt.Errorf("%w: fixed-point value %g overflows with scale %g and offset %g", ErrInvalidData, v, scale, offset)
+	}
+	return int32(raw), nil
+}
+
 // Build DE packet from parameters then append it to unbuilt packet
 func (pkt *BuildPkt) AppendDEPkt(g DEGroup, id byte, t DEtype, dlen uint16, data []byte) {
 	pkt.Append([]byte{byte(g), id, byte(t)})
@@ -126,6 +169,22 @@ func (pkt *BuildPkt) AppendDEPktFixed(g DEGroup, id byte, t DEtype, dlen uint16,
 	}
 }
 
+// Whether DE type value is carried by DataRaw rather than Data
+// Unknown DE types from newer peers are kept as raw bytes
+func DEtypeUsesRaw(t DEtype) bool {
+	return t == DEtypeRaw || t == DEtypeString || t == DEtypeFixed || t > DEtypeFixed
+}
+
+// Append DE packet to unbuilt packet
+// Raw, String and Fixed use dep.DataRaw while other types use dep.Data
+func (pkt *BuildPkt) AppendDEP(dep DePkt) {
+	if !DEtypeUsesRaw(dep.Dtype) {
+		pkt.AppendDEPktFixed(dep.Group, dep.Id, dep.Dtype, dep.Dlen, dep.Data)
+	} else {
+		pkt.AppendDEPkt(dep.Group, dep.Id, dep.Dtype, dep.Dlen, dep.DataRaw)
+	}
+}
+
 // Transform unbuilt packet into base packet
 func (p BuildPkt) Build() BasePkt {
 	Pkt := BasePkt{Ver: p.Ver, CommandID: p.CommandID, DataLen: p.DataLen, Data: p.Data}
@@ -174,11 +233,41 @@ func (t DEtype) String() string {
 		return "Bmap2"
 	case DEtypeBmap4:
 		return "Bmap4"
+	case DEtypeInt8:
+		return "Int8"
+	case DEtypeInt16:
+		return "Int16"
+	case DEtypeInt32:
+		return "Int32"
+	case DEtypeFloat:
+		return "Float"
+	case DEtypeFixed:
+		return "Fixed"
 	default:
 		return "Invalid"
 	}
 }
 
+// Parse DE group name as printed by its String method, ignoring case
+func ParseDEGroup(s string) (DEGroup, error) {
+	for g := DegInfo; g <= DegControl; g++ {
+		if strings.EqualFold(s, g.String()) {
+			return g, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: DE group %q", ErrInvalidData, s)
+}
+
+// Parse DE type name as printed by its String method, ignoring case
+func ParseDEtype(s string) (DEtype, error) {
+	for t := DEtypeRaw; t <= DEtypeFixed; t++ {
+		if strings.EqualFold(s, t.String()) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: DE type %q", ErrInvalidData, s)
+}
+
 func (p DePkt) String() string {
 	switch p.Dtype {
 	case DEtypeRaw:
@@ -187,6 +276,15 @@ func (p DePkt) String() string {
 	case DEtypeString:
 		return fmt.Sprintf("group: %s id: %d dtype: %s dlen: %d dataRaw:[0x%x] data: %s",
 			p.Group, p.Id, p.Dtype, p.Dlen, p.DataRaw, p.DataRaw)
+	case DEtypeInt8, DEtypeInt16, DEtypeInt32:
+		return fmt.Sprintf("group: %s id: %d dtype: %s dlen: %d dataRaw:[0x%x] data: %d",
+			p.Group, p.Id, p.Dtype, p.Dlen, p.DataRaw, DepIntData(p))
+	case DEtypeFloat:
+		return fmt.Sprintf("group: %s id: %d dtype: %s dlen: %d dataRaw:[0x%x] data: %s",
+			p.Group, p.Id, p.Dtype, p.Dlen, p.DataRaw, strconv.FormatFloat(DepFloatData(p), 'g', -1, 32))
+	case DEtypeFixed:
+		return fmt.Sprintf("group: %s id: %d dtype: %s dlen: %d dataRaw:[0x%x] data: %s",
+			p.Group, p.Id, p.Dtype, p.Dlen, p.DataRaw, strconv.FormatFloat(DepFloatData(p), 'g', -1, 64))
 	default:
 		return fmt.Sprintf("group: %s id: %d dtype: %s dlen: %d dataRaw:[0x%x] data: %d",
 			p.Group, p.Id, p.Dtype, p.Dlen, p.DataRaw, p.Data)
@@ -194,94 +292,67 @@ func (p DePkt) String() string {
 }
 
 func (p SchPkt) String() string {
-	return fmt.Sprintf("id: %d wdays: %08b hour: %d minute: %d dep:[%s]", p.Id, p.Weekdays, p.Hour, p.Minute, p.Dep)
+	return fmt.Sprintf("id: %d wdays: %s hour: %d minute: %d dep:[%s]", p.Id, p.Wdays(), p.Hour, p.Minute, p.Dep)
 }
 
 // Make handshake packet
 func MkHandshake(msg []byte) []byte {
-	p := Create(CmdHandshake)
-	p.Append(msg)
-	return p.Build().Buf
+	return AppendHandshake(nil, msg)
 }
 
 // Make all uplink info request packet
 func MkUinfoReqAll() []byte {
-	p := Create(CmdUplinkInfo)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdUplinkInfo, nil)
 }
 
 // Make uplink info request packet
 func MkUinfoReq(rb DeviceInfoRB) []byte {
-	p := Create(CmdUplinkInfo)
-	p.AppendOne(rb)
-	return p.Build().Buf
+	return AppendUinfoReq(nil, rb)
 }
 
 // Make uplink info response packet
 func MkUinfoResp(rb DeviceInfoRB, resp string) []byte {
-	p := Create(CmdUplinkInfo)
-	p.AppendOne(rb)
-	p.Append([]byte(resp))
-	return p.Build().Buf
+	return AppendUinfoResp(nil, rb, resp)
 }
 
 // Make network reset request packet
 func MkNetResetReq(rb NetRstRB) []byte {
-	p := Create(CmdNetworkReset)
-	p.AppendOne(rb)
-	return p.Build().Buf
+	return AppendNetResetReq(nil, rb)
 }
 
 // Make network reset acknowledgement packet
 func MkNetResetACK() []byte {
-	p := Create(CmdNetworkReset)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdNetworkReset, nil)
 }
 
 // Make network status report acknowledgement packet
 func MkNetStatusReportACK() []byte {
-	p := Create(CmdNetworkStatus)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdNetworkStatus, nil)
 }
 
 // Make network status report packet
 func MkNetStatusReport(r NetstatData) []byte {
-	p := Create(CmdNetworkStatus)
-	p.AppendOne(r)
-	return p.Build().Buf
+	return AppendNetStatusReport(nil, r)
 }
 
 // Make time synchronization not ready packet
 func MkTsyncNotReady() []byte {
-	p := Create(CmdTimeSync)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdTimeSync, nil)
 }
 
 // Make time synchronization request packet
 func MkTsyncReq(rb TimesyncRB) []byte {
-	p := Create(CmdTimeSync)
-	p.AppendOne(rb)
-	return p.Build().Buf
+	return AppendTsyncReq(nil, rb)
 }
 
 // Make time synchronization response packet
 func MkTsyncResp(rb TimesyncRB, tm time.Time) []byte {
-	p := Create(CmdTimeSync)
-	p.AppendOne(rb)
-	p.AppendOne(byte(tm.Year() - 100))
-	p.AppendOne(byte(tm.Month()))
-	p.AppendOne(byte(tm.Day()))
-	p.AppendOne(byte(tm.Weekday()))
-	p.AppendOne(byte(tm.Hour()))
-	p.AppendOne(byte(tm.Minute()))
-	p.AppendOne(byte(tm.Second()))
-	return p.Build().Buf
+	return AppendTsyncResp(nil, rb, tm)
 }
 
 // Make DE reset request packet
 func MkDeResetAllReq() []byte {
-	p := Create(CmdDESet)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdDESet, nil)
 }
 
 // Enforce DE data length for DE data types with fixed length
@@ -299,6 +370,16 @@ func EnforceDElen(t DEtype, dlen uint16) uint16 {
 		return LenDeBmap2
 	case DEtypeBmap4:
 		return LenDeBmap4
+	case DEtypeInt8:
+		return LenDeInt8
+	case DEtypeInt16:
+		return LenDeInt16
+	case DEtypeInt32:
+		return LenDeInt32
+	case DEtypeFloat:
+		return LenDeFloat
+	case DEtypeFixed:
+		return LenDeFixed
 	default:
 		return dlen
 	}
@@ -306,260 +387,327 @@ func EnforceDElen(t DEtype, dlen uint16) uint16 {
 
 // Make DE set packet
 func MkDES(g DEGroup, id byte, t DEtype, dlen uint16, data []byte) []byte {
-	p := Create(CmdDESet)
-	dlen = EnforceDElen(t, dlen)
-	p.AppendDEPkt(g, id, t, dlen, data)
-	return p.Build().Buf
+	return AppendDES(nil, g, id, t, dlen, data)
 }
 
 // Make DE set packet: Raw
 func MkDeSetRaw(g DEGroup, id byte, data []byte) []byte {
-	return MkDES(g, id, DEtypeRaw, uint16(len(data)), data)
+	return AppendDeSetRaw(nil, g, id, data)
 }
 
 // Make DE set packet: String
 func MkDeSetStr(g DEGroup, id byte, data string) []byte {
-	return MkDES(g, id, DEtypeString, uint16(len(data)), []byte(data))
+	return AppendDeSetStr(nil, g, id, data)
 }
 
 // Make DE set packet: Boolean
 func MkDeSetBool(g DEGroup, id byte, data bool) []byte {
-	var d byte = 0
-	if data {
-		d = 1
-	}
-	return MkDES(g, id, DEtypeBool, LenDeBool, []byte{d})
+	return AppendDeSetBool(nil, g, id, data)
 }
 
 // Make DE set packet: Enumeration
 func MkDeSetEnum(g DEGroup, id byte, data byte) []byte {
-	return MkDES(g, id, DEtypeEnum, LenDeEnum, []byte{data})
+	return AppendDeSetEnum(nil, g, id, data)
 }
 
 // Make DE set packet: Uint/value
 func MkDeSetUint(g DEGroup, id byte, data uint32) []byte {
-	dataBig := U32ToBslice(data)
-	return MkDES(g, id, DEtypeUint, LenDeUint, dataBig)
+	return AppendDeSetUint(nil, g, id, data)
 }
 
 // Make DE set packet: 1-byte bitmap
 func MkDeSetBmap1(g DEGroup, id byte, data byte) []byte {
-	return MkDES(g, id, DEtypeBmap1, LenDeBmap1, []byte{data})
+	return AppendDeSetBmap1(nil, g, id, data)
 }
 
 // Make DE set packet: 2-byte bitmap
 func MkDeSetBmap2(g DEGroup, id byte, data uint16) []byte {
-	dataBig := U16ToBslice(data)
-	return MkDES(g, id, DEtypeBmap2, LenDeBmap2, dataBig)
+	return AppendDeSetBmap2(nil, g, id, data)
 }
 
 // Make DE set packet: 4-byte bitmap
 func MkDeSetBmap4(g DEGroup, id byte, data uint32) []byte {
-	dataBig := U32ToBslice(data)
-	return MkDES(g, id, DEtypeBmap4, LenDeBmap4, dataBig)
+	return AppendDeSetBmap4(nil, g, id, data)
+}
+
+// Make DE set packet: 8-bit signed integer
+func MkDeSetInt8(g DEGroup, id byte, data int8) []byte {
+	return AppendDeSetInt8(nil, g, id, data)
+}
+
+// Make DE set packet: 16-bit signed integer
+func MkDeSetInt16(g DEGroup, id byte, data int16) []byte {
+	return AppendDeSetInt16(nil, g, id, data)
+}
+
+// Make DE set packet: 32-bit signed integer
+func MkDeSetInt32(g DEGroup, id byte, data int32) []byte {
+	return AppendDeSetInt32(nil, g, id, data)
+}
+
+// Make DE set packet: float32
+func MkDeSetFloat(g DEGroup, id byte, data float32) []byte {
+	return AppendDeSetFloat(nil, g, id, data)
+}
+
+// Make DE set packet: Fixed-point, data is encoded as round((data-offset)/scale)
+// Data rejected by FixedRaw is encoded with the saturated raw value it returns
+func MkDeSetFixed(g DEGroup, id byte, data float64, scale float32, offset float32) []byte {
+	return AppendDeSetFixed(nil, g, id, data, scale, offset)
+}
+
+// Make DE set packet containing all DE in depList
+func MkDESList(depList []DePkt) []byte {
+	return AppendDESList(nil, depList)
 }
 
 // Make DE report packet
 func MkDER(g DEGroup, id byte, t DEtype, dlen uint16, data []byte) []byte {
-	p := Create(CmdDEReport)
-	dlen = EnforceDElen(t, dlen)
-	p.AppendDEPkt(g, id, t, dlen, data)
-	return p.Build().Buf
+	return AppendDER(nil, g, id, t, dlen, data)
 }
 
 // Make DE report packet: Raw
 func MkDeRepRaw(g DEGroup, id byte, data []byte) []byte {
-	return MkDER(g, id, DEtypeRaw, uint16(len(data)), data)
+	return AppendDeRepRaw(nil, g, id, data)
 }
 
 // Make DE report packet: String
 func MkDeRepStr(g DEGroup, id byte, data string) []byte {
-	return MkDER(g, id, DEtypeString, uint16(len(data)), []byte(data))
+	return AppendDeRepStr(nil, g, id, data)
 }
 
 // Make DE report packet: Boolean
 func MkDeRepBool(g DEGroup, id byte, data bool) []byte {
-	var d byte = 0
-	if data {
-		d = 1
-	}
-	return MkDER(g, id, DEtypeBool, LenDeBool, []byte{d})
+	return AppendDeRepBool(nil, g, id, data)
 }
 
 // Make DE report packet: Enumeration
 func MkDeRepEnum(g DEGroup, id byte, data byte) []byte {
-	return MkDER(g, id, DEtypeEnum, LenDeEnum, []byte{data})
+	return AppendDeRepEnum(nil, g, id, data)
 }
 
 // Make DE report packet: Uint/value
 func MkDeRepUint(g DEGroup, id byte, data uint32) []byte {
-	dataBig := U32ToBslice(data)
-	return MkDER(g, id, DEtypeUint, LenDeUint, dataBig)
+	return AppendDeRepUint(nil, g, id, data)
 }
 
 // Make DE report packet: 1-Byte bitmap
 func MkDeRepBmap1(g DEGroup, id byte, data byte) []byte {
-	return MkDER(g, id, DEtypeBmap1, LenDeBmap1, []byte{data})
+	return AppendDeRepBmap1(nil, g, id, data)
 }
 
 // Make DE report packet: 2-Byte bitmap
 func MkDeRepBmap2(g DEGroup, id byte, data uint16) []byte {
-	dataBig := U16ToBslice(data)
-	return MkDER(g, id, DEtypeBmap2, LenDeBmap2, dataBig)
+	return AppendDeRepBmap2(nil, g, id, data)
 }
 
 // Make DE report packet: 4-Byte bitmap
 func MkDeRepBmap4(g DEGroup, id byte, data uint32) []byte {
-	dataBig := U32ToBslice(data)
-	return MkDER(g, id, DEtypeBmap4, LenDeBmap4, dataBig)
+	return AppendDeRepBmap4(nil, g, id, data)
+}
+
+// Make DE report packet: 8-bit signed integer
+func MkDeRepInt8(g DEGroup, id byte, data int8) []byte {
+	return AppendDeRepInt8(nil, g, id, data)
+}
+
+// Make DE report packet: 16-bit signed integer
+func MkDeRepInt16(g DEGroup, id byte, data int16) []byte {
+	return AppendDeRepInt16(nil, g, id, data)
+}
+
+// Make DE report packet: 32-bit signed integer
+func MkDeRepInt32(g DEGroup, id byte, data int32) []byte {
+	return AppendDeRepInt32(nil, g, id, data)
+}
+
+// Make DE report packet: float32
+func MkDeRepFloat(g DEGroup, id byte, data float32) []byte {
+	return AppendDeRepFloat(nil, g, id, data)
+}
+
+// Make DE report packet: Fixed-point, data is encoded as round((data-offset)/scale)
+// Data rejected by FixedRaw is encoded with the saturated raw value it returns
+func MkDeRepFixed(g DEGroup, id byte, data float64, scale float32, offset float32) []byte {
+	return AppendDeRepFixed(nil, g, id, data, scale, offset)
+}
+
+// Make DE report packet containing all DE in depList
+func MkDERList(depList []DePkt) []byte {
+	return AppendDERList(nil, depList)
 }
 
 // Make DE fault report request packet
 func MkDeFaultAllReq() []byte {
-	p := Create(CmdDEFault)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdDEFault, nil)
 }
 
 // Make DE fault report packet: No fault on all DE
 func MkDeFaultNoneAll() []byte {
-	p := Create(CmdDEFault)
-	p.AppendOne(0)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdDEFault, []byte{DefNone})
 }
 
 // Make DE fault acknowledgement packet
 func MkDeFaultAck(g DEGroup, id byte) []byte {
-	p := Create(CmdDEFault)
-	p.AppendOne(byte(g))
-	p.AppendOne(id)
-	return p.Build().Buf
+	return AppendDeFaultAck(nil, g, id)
 }
 
 // Make DE fault report packet
 func MkDeFaultRep(g DEGroup, id byte, f DEF) []byte {
-	p := Create(CmdDEFault)
-	p.AppendOne(byte(g))
-	p.AppendOne(id)
-	p.AppendOne(f)
-	return p.Build().Buf
+	return AppendDeFaultRep(nil, g, id, f)
 }
 
 // Make schedule clear request packet
 func MkSchEraseAllReq() []byte {
-	p := Create(CmdSchedule)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdSchedule, nil)
 }
 
 // Make schedule execution report packet
 func MkSchExecReport(schId byte) []byte {
-	p := Create(CmdSchedule)
-	p.AppendOne(schId)
-	return p.Build().Buf
+	return AppendSchExecReport(nil, schId)
 }
 
 // Make schedule set packet
+// MkSchSet does no validation and must not be given unchecked input,
+// invalid schedules are encoded as is and empty schList makes the same packet as MkSchExecReport(0)
+// Use AppendSchSet for schedule lists not already passing ValidateSchList
 func MkSchSet(schList []SchPkt) []byte {
-	p := Create(CmdSchedule)
-	p.AppendOne(byte(len(schList)))
-	for _, sch := range schList {
-		p.AppendOne(sch.Id)
-		p.AppendOne(sch.Weekdays)
-		p.AppendOne(sch.Hour)
-		p.AppendOne(sch.Minute)
-		dep := sch.Dep
-		if sch.Dep.Dtype != DEtypeRaw && sch.Dep.Dtype != DEtypeString {
-			p.AppendDEPktFixed(dep.Group, dep.Id, dep.Dtype, dep.Dlen, dep.Data)
-		} else {
-			p.AppendDEPkt(dep.Group, dep.Id, dep.Dtype, dep.Dlen, dep.DataRaw)
-		}
-	}
-	return p.Build().Buf
+	return appendSchSet(nil, schList)
 }
 
 // Make software update iniitiate packet
 func MkSwupInitiate() []byte {
-	p := Create(CmdSwUpdate)
-	return p.Build().Buf
+	return AppendPkt(nil, CmdSwUpdate, nil)
 }
 
 // Make sofware update simple reply packet
 func MkSwupSrep(srep SwupSrep) []byte {
-	p := Create(CmdSwUpdate)
-	p.AppendOne(srep)
-	return p.Build().Buf
+	return AppendSwupSrep(nil, srep)
 }
 
 // Make sofware update chunk size set packet
 func MkSwupSetChunksz(chunksz uint16) []byte {
-	p := Create(CmdSwUpdate)
-	dataBig := U16ToBslice(chunksz)
-	p.Append(dataBig)
-	return p.Build().Buf
+	return AppendSwupSetChunksz(nil, chunksz)
 }
 
 // Make sofware update status packet
 func MkSwupStatus(finished bool, success bool, err SwupErr) []byte {
-	p := Create(CmdSwUpdate)
-	d := []byte{0, 0, 0}
-	if finished {
-		d[IdxSwupStatFinished] = 1
-	}
-	if success {
-		d[IdxSwupStatSuccess] = 1
-	}
-	d[IdxSwupStatError] = err
-	p.Append(d)
-	return p.Build().Buf
+	return AppendSwupStatus(nil, finished, success, err)
 }
 
 // Make sofware update chunk request packet
 func MkSwupChunkReq(chunkidx uint32) []byte {
-	p := Create(CmdSwUpdate)
-	dataBig := U32ToBslice(chunkidx)
-	p.Append(dataBig)
-	return p.Build().Buf
+	return AppendSwupChunkReq(nil, chunkidx)
 }
 
 // Make sofware update chunk request packet
 func MkSwupChunk(chunkidx uint32, chunk []byte) []byte {
-	p := Create(CmdSwUpdate)
-	dataBig := U32ToBslice(chunkidx)
-	p.Append(dataBig)
-	p.Append(chunk)
-	return p.Build().Buf
+	return AppendSwupChunk(nil, chunkidx, chunk)
+}
+
+// Make parse error at data offset off of base packet
+func (p BasePkt) errAt(err error, off int, field string, expected, actual any) error {
+	return &ParseError{Err: err, Cmd: p.CommandID, HasCmd: true, Offset: int(IdxData) + off, Field: field, Expected: expected, Actual: actual}
+}
+
+// Move parse error of buffer found at offset off of enclosing buffer into enclosing buffer
+// prefix is prepended to failing field of the buffer
+func nestParseErr(err error, off int, prefix string) *ParseError {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return nil
+	}
+	nested := *pe
+	nested.Offset += off
+	nested.Field = prefix + nested.Field
+	return &nested
+}
+
+// Move parse error of buffer found at data offset off of base packet into base packet
+func (p BasePkt) nestErr(err error, off int, prefix string) error {
+	nested := nestParseErr(err, int(IdxData)+off, prefix)
+	if nested == nil {
+		return err
+	}
+	nested.Cmd, nested.HasCmd = p.CommandID, true
+	return nested
+}
+
+// Check that data length field matches data, so hand-built base packets are safe to index
+func (p BasePkt) checkDataLen() error {
+	if int(p.DataLen) != len(p.Data) {
+		return &ParseError{Err: ErrLenMismatch, Cmd: p.CommandID, HasCmd: true, Offset: int(IdxDlen), Field: "dlen",
+			Expected: len(p.Data), Actual: int(p.DataLen)}
+	}
+	return nil
 }
 
-// Parse buffer into base packet
+// Parse buffer into base packet owning a copy of buf
+// Data and Buf of returned packet share that copy so buf may be reused right away
 func Parse(buf []byte) (BasePkt, error) {
+	pkt := BasePkt{}
+	if err := ParseInto(&pkt, buf); err != nil {
+		return BasePkt{}, err
+	}
+	return pkt, nil
+}
+
+// Parse buffer into p, copying buf into storage of p.Buf
+// p is left unchanged when buf is not a valid packet
+func ParseInto(p *BasePkt, buf []byte) error {
+	pkt, err := ParseView(buf)
+	if err != nil {
+		return err
+	}
+	pkt.Buf = append(p.Buf[:0], buf...)
+	pkt.Data = pkt.Buf[IdxData : int(IdxData)+int(pkt.DataLen)]
+	*p = pkt
+	return nil
+}
+
+// Parse buffer into base packet without copying
+// Data and Buf of returned packet are views of buf and only valid until buf is modified
+func ParseView(buf []byte) (BasePkt, error) {
 	if len(buf) < int(LenPktMin) {
-		return BasePkt{}, ErrTooShort
+		return BasePkt{}, &ParseError{Err: ErrTooShort, Field: "packet length", Expected: int(LenPktMin), Actual: len(buf)}
 	}
 	if buf[IdxHead1] != Head1 {
-		return BasePkt{}, fmt.Errorf("%w: Header 1", ErrInvalidData)
+		return BasePkt{}, &ParseError{Err: ErrInvalidData, Offset: int(IdxHead1), Field: "header 1", Expected: Head1, Actual: buf[IdxHead1]}
 	}
 	if buf[IdxHead2] != Head2 {
-		return BasePkt{}, fmt.Errorf("%w: Header 2", ErrInvalidData)
-	}
-	err := ChksumVerify(buf[:len(buf)-1], buf[len(buf)-1])
-	if err != nil {
-		return BasePkt{}, err
+		return BasePkt{}, &ParseError{Err: ErrInvalidData, Offset: int(IdxHead2), Field: "header 2", Expected: Head2, Actual: buf[IdxHead2]}
 	}
 
 	pkt := BasePkt{Ver: buf[IdxVer], CommandID: CmdID(buf[IdxCmd])}
+	if chksum := Chksum(buf[:len(buf)-1]); chksum != buf[len(buf)-1] {
+		return BasePkt{}, &ParseError{Err: ErrChksum, Cmd: pkt.CommandID, HasCmd: true, Offset: len(buf) - 1, Field: "chksum",
+			Expected: chksum, Actual: buf[len(buf)-1]}
+	}
 	dlenSlice := buf[IdxDlen : IdxDlen+LenDlen]
 	pkt.DataLen = binary.BigEndian.Uint16(dlenSlice)
 	if len(buf) != int(LenPktMin)+int(pkt.DataLen) {
-		return BasePkt{}, ErrLenMismatch
+		return BasePkt{}, &ParseError{Err: ErrLenMismatch, Cmd: pkt.CommandID, HasCmd: true, Offset: int(IdxDlen), Field: "dlen",
+			Expected: len(buf) - int(LenPktMin), Actual: int(pkt.DataLen)}
 	}
-	pkt.Data = buf[IdxData : uint16(IdxData)+pkt.DataLen]
-	pkt.Buf = append(pkt.Buf, buf...)
+	pkt.Data = buf[IdxData : int(IdxData)+int(pkt.DataLen)]
+	pkt.Buf = buf
 	pkt.Chksum = buf[len(buf)-1]
 
 	return pkt, nil
 }
 
 // Get numeric data from DE packet with fixed-length data type
+// Fixed-point DE yields its raw value
 func DepFixedData(p DePkt) uint32 {
+	if p.Dtype == DEtypeFixed {
+		if len(p.DataRaw) < int(LenDeFixed) {
+			return 0
+		}
+		return binary.BigEndian.Uint32(p.DataRaw[IdxDeFixedRaw:])
+	}
+	if len(p.DataRaw) < int(p.Dlen) {
+		return 0
+	}
 	if p.Dlen == 1 {
 		return uint32(p.DataRaw[0])
 	} else if p.Dlen == 2 {
@@ -572,10 +720,95 @@ func DepFixedData(p DePkt) uint32 {
 	return 0
 }
 
-// Parse buffer into DE packet
+// Get signed integer data from DE packet with signed integer type
+func DepIntData(p DePkt) int32 {
+	switch p.Dtype {
+	case DEtypeInt8:
+		return int32(int8(p.Data))
+	case DEtypeInt16:
+		return int32(int16(p.Data))
+	default:
+		return int32(p.Data)
+	}
+}
+
+// Get raw value, scale and offset from fixed-point DE packet
+func DepFixedPoint(p DePkt) (raw int32, scale float32, offset float32) {
+	if len(p.DataRaw) < int(LenDeFixed) {
+		return 0, 0, 0
+	}
+	raw = int32(binary.BigEndian.Uint32(p.DataRaw[IdxDeFixedRaw:]))
+	scale = math.Float32frombits(binary.BigEndian.Uint32(p.DataRaw[IdxDeFixedScale:]))
+	offset = math.Float32frombits(binary.BigEndian.Uint32(p.DataRaw[IdxDeFixedOffset:]))
+	return raw, scale, offset
+}
+
+// Count of decimal places in shortest representation of f
+func fixedDecimals(f float32) int {
+	str := strconv.FormatFloat(float64(f), 'f', -1, 32)
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		return len(str) - i - 1
+	}
+	return 0
+}
+
+// Get real value from DE packet with numeric type
+func DepFloatData(p DePkt) float64 {
+	switch p.Dtype {
+	case DEtypeFloat:
+		return float64(math.Float32frombits(p.Data))
+	case DEtypeFixed:
+		raw, scale, offset := DepFixedPoint(p)
+		v := float64(raw)*float64(scale) + float64(offset)
+		// Round to decimal places of scale and offset so 0.1 steps read back as written
+		dec := fixedDecimals(scale)
+		if d := fixedDecimals(offset); d > dec {
+			dec = d
+		}
+		pow := math.Pow10(dec)
+		return math.Round(v*pow) / pow
+	case DEtypeInt8, DEtypeInt16, DEtypeInt32:
+		return float64(DepIntData(p))
+	default:
+		return float64(p.Data)
+	}
+}
+
+// Parse buffer into DE packet owning a copy of its bytes in buf
+// DataRaw and Buf of returned DE packet share that copy so buf may be reused right away
 func ParseDEP(buf []byte) (DePkt, error) {
+	return parseDEP(buf, "DE")
+}
+
+// Parse buffer into owning DE packet, naming failing fields after name
+func parseDEP(buf []byte, name string) (DePkt, error) {
+	dep, err := parseDEPView(buf, name)
+	if err != nil {
+		return DePkt{}, err
+	}
+	dep.Buf = append([]byte(nil), dep.Buf...)
+	dep.DataRaw = dep.Buf[IdxDEPdata:]
+	return dep, nil
+}
+
+// Copy of DE packet owning its data
+// Buf is dropped so the copy never aliases packet buffers
+func cloneDEP(dep DePkt) DePkt {
+	dep.DataRaw = append([]byte(nil), dep.DataRaw...)
+	dep.Buf = nil
+	return dep
+}
+
+// Parse buffer into DE packet without copying
+// DataRaw and Buf of returned DE packet are views of buf and only valid until buf is modified
+func ParseDEPView(buf []byte) (DePkt, error) {
+	return parseDEPView(buf, "DE")
+}
+
+// Parse buffer into DE packet without copying, naming failing fields after name
+func parseDEPView(buf []byte, name string) (DePkt, error) {
 	if len(buf) < int(LenDePktMin) {
-		return DePkt{}, ErrTooShort
+		return DePkt{}, &ParseError{Err: ErrTooShort, Field: name + " length", Expected: int(LenDePktMin), Actual: len(buf)}
 	}
 	dep := DePkt{}
 	dep.Group = DEGroup(buf[IdxDEPGroup])
@@ -584,11 +817,16 @@ func ParseDEP(buf []byte) (DePkt, error) {
 	dlenSlice := buf[IdxDEPdlen : IdxDEPdlen+LenDlen]
 	dep.Dlen = binary.BigEndian.Uint16(dlenSlice)
 	if len(buf) < int(LenDePktMin)+int(dep.Dlen) {
-		return DePkt{}, ErrLenMismatch
+		return DePkt{}, &ParseError{Err: ErrLenMismatch, Offset: int(IdxDEPdlen), Field: name + " dlen",
+			Expected: len(buf) - int(LenDePktMin), Actual: int(dep.Dlen)}
+	}
+	// Signed, float and fixed-point types have no legacy senders with other lengths
+	if dep.Dtype >= DEtypeInt8 && dep.Dtype <= DEtypeFixed && dep.Dlen != EnforceDElen(dep.Dtype, 0) {
+		return DePkt{}, &ParseError{Err: ErrLenMismatch, Offset: int(IdxDEPdlen), Field: name + " dlen",
+			Expected: int(EnforceDElen(dep.Dtype, 0)), Actual: int(dep.Dlen)}
 	}
-	dataSlice := buf[IdxDEPdata : IdxDEPdata+byte(dep.Dlen)]
-	dep.DataRaw = append(dep.DataRaw, dataSlice...)
-	dep.Buf = buf[:uint16(LenDePktMin)+dep.Dlen]
+	dep.DataRaw = buf[IdxDEPdata : int(IdxDEPdata)+int(dep.Dlen)]
+	dep.Buf = buf[:int(LenDePktMin)+int(dep.Dlen)]
 
 	if dep.Dtype != DEtypeRaw && dep.Dtype != DEtypeString {
 		dep.Data = DepFixedData(dep)
@@ -605,36 +843,99 @@ func (p BasePkt) GetDEP() (DePkt, error) {
 	if p.CommandID != CmdDESet && p.CommandID != CmdDEReport {
 		return dep, ErrCmdId
 	}
-	return ParseDEP(p.Data)
+	dep, err := ParseDEP(p.Data)
+	if err != nil {
+		return DePkt{}, p.nestErr(err, 0, "")
+	}
+	return dep, nil
+}
+
+// Get all DE packets from base packet
+func (p BasePkt) GetDEPList() ([]DePkt, error) {
+	if p.CommandID != CmdDESet && p.CommandID != CmdDEReport {
+		return []DePkt{}, ErrCmdId
+	}
+	depList := []DePkt{}
+	for pIdx := 0; pIdx < len(p.Data); {
+		dep, err := parseDEP(p.Data[pIdx:], fmt.Sprintf("DE %d", len(depList)))
+		if err != nil {
+			return []DePkt{}, p.nestErr(err, pIdx, "")
+		}
+		depList = append(depList, dep)
+		pIdx += len(dep.Buf)
+	}
+	return depList, nil
 }
 
-// Get schedule list from base packet
+// Get schedule list from schedule set packet
 func (p BasePkt) GetSchList() ([]SchPkt, error) {
-	var err error = nil
+	sch, err := p.GetSch()
+	if err != nil {
+		return []SchPkt{}, err
+	}
+	switch sch.Kind {
+	case SchKindEraseAll:
+		return []SchPkt{}, fmt.Errorf("%w: erase all request is not a schedule set", ErrSchedule)
+	case SchKindExecReport:
+		return []SchPkt{}, fmt.Errorf("%w: execution report is not a schedule set", ErrSchedule)
+	}
+	return sch.List, nil
+}
+
+// Get schedule command info from base packet
+func (p BasePkt) GetSch() (Sch, error) {
 	if p.CommandID != CmdSchedule {
-		return []SchPkt{}, ErrCmdId
+		return Sch{}, ErrCmdId
+	}
+	if err := p.checkDataLen(); err != nil {
+		return Sch{}, err
+	}
+	switch p.DataLen {
+	case LenSchDataEraseAll:
+		return Sch{Kind: SchKindEraseAll}, nil
+	case LenSchDataExecReport:
+		return Sch{Kind: SchKindExecReport, ExecId: p.Data[0]}, nil
 	}
 
-	schNum := p.Data[0]
-	schList := make([]SchPkt, schNum)
+	schList := make([]SchPkt, p.Data[0])
 	pIdx := 1
 	for i := range schList {
-		sch := &schList[i]
-		sch.Id = p.Data[pIdx+int(IdxSchpID)]
-		sch.Weekdays = p.Data[pIdx+int(IdxSchpWday)]
-		sch.Hour = p.Data[pIdx+int(IdxSchpHour)]
-		sch.Minute = p.Data[pIdx+int(IdxSchpMinute)]
-		sch.Dep, err = ParseDEP(p.Data[pIdx+int(IdxSchpDep):])
+		sch, err := parseSchPkt(p.Data[pIdx:])
 		if err != nil {
-			return []SchPkt{}, fmt.Errorf("%w %w on schedule id %d", ErrSchedule, err, sch.Id)
+			err = p.nestErr(err, pIdx, fmt.Sprintf("schedule %d ", i))
+			return Sch{}, fmt.Errorf("%w: %w", ErrSchedule, err)
 		}
+		schList[i] = sch
 		pIdx += int(LenSchHead) + int(LenDePktMin) + int(sch.Dep.Dlen)
-		if pIdx > int(p.DataLen)+1 {
-			return []SchPkt{}, ErrLenMismatch
-		}
 	}
+	if pIdx != len(p.Data) {
+		err := p.errAt(ErrLenMismatch, pIdx, fmt.Sprintf("trailing bytes after %d schedules", len(schList)), pIdx, len(p.Data))
+		return Sch{}, fmt.Errorf("%w: %w", ErrSchedule, err)
+	}
+
+	return Sch{Kind: SchKindSet, List: schList}, nil
+}
 
-	return schList, nil
+// Parse schedule header and DE at the start of buf
+func parseSchPkt(buf []byte) (SchPkt, error) {
+	if len(buf) < int(LenSchHead)+int(LenDePktMin) {
+		return SchPkt{}, &ParseError{Err: ErrTooShort, Field: "header", Expected: int(LenSchHead) + int(LenDePktMin), Actual: len(buf)}
+	}
+	sch := SchPkt{
+		Id:       buf[IdxSchpID],
+		Weekdays: buf[IdxSchpWday],
+		Hour:     buf[IdxSchpHour],
+		Minute:   buf[IdxSchpMinute],
+	}
+	dep, err := ParseDEP(buf[IdxSchpDep:])
+	if err != nil {
+		if nested := nestParseErr(err, int(IdxSchpDep), ""); nested != nil {
+			return SchPkt{}, nested
+		}
+		return SchPkt{}, err
+	}
+	sch.Dep = dep
+	return sch, nil
 }
 
 // Get Software update command info
@@ -643,6 +944,9 @@ func (p BasePkt) GetSwup() (Swup, error) {
 	if p.CommandID != CmdSwUpdate {
 		return swup, ErrCmdId
 	}
+	if err := p.checkDataLen(); err != nil {
+		return swup, err
+	}
 
 	switch p.DataLen {
 	case LenSwupDataInitiate:
@@ -681,3 +985,168 @@ func (p BasePkt) GetSwup() (Swup, error) {
 	}
 	return swup, nil
 }
+
+// Get handshake message from base packet
+func (p BasePkt) GetHandshake() ([]byte, error) {
+	if p.CommandID != CmdHandshake {
+		return nil, ErrCmdId
+	}
+	return p.Data, nil
+}
+
+// Get uplink info request or response from base packet
+func (p BasePkt) GetUinfo() (Uinfo, error) {
+	uinfo := Uinfo{}
+	if p.CommandID != CmdUplinkInfo {
+		return uinfo, ErrCmdId
+	}
+	if len(p.Data) == 0 {
+		uinfo.All = true
+		return uinfo, nil
+	}
+	uinfo.Rb = p.Data[IdxDevInfoReqbyte]
+	if uinfo.Rb > DeviceID {
+		return Uinfo{}, p.errAt(ErrInvalidData, int(IdxDevInfoReqbyte), "device info request byte", nil, uinfo.Rb)
+	}
+	if len(p.Data) > int(IdxDevInfoResp) {
+		uinfo.IsResp = true
+		uinfo.Resp = string(p.Data[IdxDevInfoResp:])
+	}
+	return uinfo, nil
+}
+
+// Get network reset request or acknowledgement from base packet
+func (p BasePkt) GetNetReset() (NetReset, error) {
+	nr := NetReset{}
+	if p.CommandID != CmdNetworkReset {
+		return nr, ErrCmdId
+	}
+	switch len(p.Data) {
+	case 0:
+		nr.Ack = true
+	case 1:
+		nr.Rb = p.Data[0]
+		if nr.Rb > NetQC {
+			return NetReset{}, p.errAt(ErrInvalidData, 0, "network reset request byte", nil, nr.Rb)
+		}
+	default:
+		return NetReset{}, p.errAt(ErrLenMismatch, 0, "network reset data length", 1, len(p.Data))
+	}
+	return nr, nil
+}
+
+// Get network status report or acknowledgement from base packet
+func (p BasePkt) GetNetStatus() (NetStatus, error) {
+	ns := NetStatus{}
+	if p.CommandID != CmdNetworkStatus {
+		return ns, ErrCmdId
+	}
+	switch len(p.Data) {
+	case 0:
+		ns.Ack = true
+	case 1:
+		ns.Data = p.Data[0]
+		if ns.Data > NetstatOk && (ns.Data < NetstatCfgAP || ns.Data > NetstatCfgQC) {
+			return NetStatus{}, p.errAt(ErrInvalidData, 0, "network status", nil, ns.Data)
+		}
+	default:
+		return NetStatus{}, p.errAt(ErrLenMismatch, 0, "network status data length", 1, len(p.Data))
+	}
+	return ns, nil
+}
+
+// Get time synchronization info from base packet
+// Response year is decoded within 2000-2255 and time is in UTC or time.Local depending on request byte
+func (p BasePkt) GetTsync() (Tsync, error) {
+	ts := Tsync{}
+	if p.CommandID != CmdTimeSync {
+		return ts, ErrCmdId
+	}
+	switch len(p.Data) {
+	case 0:
+		ts.NotReady = true
+		return ts, nil
+	case int(LenTsyncReq), int(LenTsync):
+	default:
+		return Tsync{}, p.errAt(ErrLenMismatch, 0, "time synchronization data length", int(LenTsync), len(p.Data))
+	}
+
+	ts.Rb = p.Data[IdxTsyncReqbyte]
+	if ts.Rb > TsyncLocal {
+		return Tsync{}, p.errAt(ErrInvalidData, int(IdxTsyncReqbyte), "time synchronization request byte", nil, ts.Rb)
+	}
+	if len(p.Data) == int(LenTsyncReq) {
+		return ts, nil
+	}
+
+	ts.IsResp = true
+	d := p.Data
+	year := int(d[IdxTsyncYear]) + 100
+	for year < 2000 {
+		year += 256
+	}
+	month := d[IdxTsyncMonth]
+	date := d[IdxTsyncDate]
+	fields := []struct {
+		idx  byte
+		name string
+		min  byte
+		max  byte
+	}{
+		{IdxTsyncMonth, "month", 1, 12},
+		{IdxTsyncDate, "date", 1, 31},
+		{IdxTsyncWeekday, "weekday", 0, 6},
+		{IdxTsyncHour, "hour", 0, 23},
+		{IdxTsyncMinute, "minute", 0, 59},
+		{IdxTsyncSecond, "second", 0, 59},
+	}
+	for _, f := range fields {
+		if d[f.idx] < f.min || d[f.idx] > f.max {
+			return Tsync{}, p.errAt(ErrInvalidData, int(f.idx), "time synchronization "+f.name,
+				fmt.Sprintf("%d-%d", f.min, f.max), int(d[f.idx]))
+		}
+	}
+	loc := time.UTC
+	if ts.Rb == TsyncLocal {
+		loc = time.Local
+	}
+	ts.Time = time.Date(year, time.Month(month), int(date),
+		int(d[IdxTsyncHour]), int(d[IdxTsyncMinute]), int(d[IdxTsyncSecond]), 0, loc)
+	if ts.Time.Day() != int(date) {
+		return Tsync{}, p.errAt(ErrInvalidData, int(IdxTsyncDate), "time synchronization date",
+			fmt.Sprintf("1-%d", time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, loc).Day()), int(date))
+	}
+	return ts, nil
+}
+
+// Get DE fault info from base packet
+func (p BasePkt) GetDEFault() (DeFault, error) {
+	def := DeFault{}
+	if p.CommandID != CmdDEFault {
+		return def, ErrCmdId
+	}
+	switch uint16(len(p.Data)) {
+	case LenDefDataReqAll:
+		def.Kind = DefKindReqAll
+	case LenDefDataNoneAll:
+		def.Kind = DefKindNoneAll
+		if p.Data[0] != DefNone {
+			return DeFault{}, p.errAt(ErrInvalidData, 0, "DE fault", DefNone, p.Data[0])
+		}
+	case LenDefDataAck:
+		def.Kind = DefKindAck
+	case LenDefDataReport:
+		def.Kind = DefKindReport
+		def.Fault = p.Data[IdxDefStatus]
+		if def.Fault > DefMalformed {
+			return DeFault{}, p.errAt(ErrInvalidData, int(IdxDefStatus), "DE fault", nil, def.Fault)
+		}
+	default:
+		return DeFault{}, p.errAt(ErrLenMismatch, 0, "DE fault data length", int(LenDefDataReport), len(p.Data))
+	}
+	if def.Kind == DefKindAck || def.Kind == DefKindReport {
+		def.Group = DEGroup(p.Data[IdxDefGroup])
+		def.Id = p.Data[IdxDefID]
+	}
+	return def, nil
+}
@@ -1,9 +1,14 @@
 package pg
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPgMk(t *testing.T) {
@@ -486,14 +491,420 @@ func TestPgMk(t *testing.T) {
 	}
 }
 
+func TestPgGet(t *testing.T) {
+	SetVer(0)
+	p, _ := Parse(MkHandshake([]byte("msg")))
+	msg, err := p.GetHandshake()
+	if err != nil || string(msg) != "msg" {
+		t.Error(err, msg)
+	}
+
+	p, _ = Parse(MkUinfoReqAll())
+	uinfo, err := p.GetUinfo()
+	if err != nil || !uinfo.All {
+		t.Error(err, uinfo)
+	}
+	p, _ = Parse(MkUinfoReq(DeviceName))
+	uinfo, err = p.GetUinfo()
+	if err != nil || uinfo.IsResp || uinfo.Rb != DeviceName {
+		t.Error(err, uinfo)
+	}
+	p, _ = Parse(MkUinfoResp(DeviceType, "generictype"))
+	uinfo, err = p.GetUinfo()
+	if err != nil || !uinfo.IsResp || uinfo.Rb != DeviceType || uinfo.Resp != "generictype" {
+		t.Error(err, uinfo)
+	}
+	p, _ = Parse(MkUinfoReq(DeviceID + 1))
+	if _, err = p.GetUinfo(); !errors.Is(err, ErrInvalidData) {
+		t.Error(err)
+	}
+
+	p, _ = Parse(MkNetResetReq(NetSC))
+	nr, err := p.GetNetReset()
+	if err != nil || nr.Ack || nr.Rb != NetSC {
+		t.Error(err, nr)
+	}
+	p, _ = Parse(MkNetResetACK())
+	nr, err = p.GetNetReset()
+	if err != nil || !nr.Ack {
+		t.Error(err, nr)
+	}
+
+	p, _ = Parse(MkNetStatusReport(NetstatCfgSC))
+	ns, err := p.GetNetStatus()
+	if err != nil || ns.Ack || ns.Data != NetstatCfgSC {
+		t.Error(err, ns)
+	}
+	p, _ = Parse(MkNetStatusReportACK())
+	ns, err = p.GetNetStatus()
+	if err != nil || !ns.Ack {
+		t.Error(err, ns)
+	}
+	p, _ = Parse(MkNetStatusReport(0xA0))
+	if _, err = p.GetNetStatus(); !errors.Is(err, ErrInvalidData) {
+		t.Error(err)
+	}
+
+	p, _ = Parse(MkTsyncNotReady())
+	ts, err := p.GetTsync()
+	if err != nil || !ts.NotReady {
+		t.Error(err, ts)
+	}
+	p, _ = Parse(MkTsyncReq(TsyncLocal))
+	ts, err = p.GetTsync()
+	if err != nil || ts.IsResp || ts.Rb != TsyncLocal {
+		t.Error(err, ts)
+	}
+	tm := time.Date(2026, time.October, 17, 7, 30, 59, 0, time.UTC)
+	p, _ = Parse(MkTsyncResp(TsyncUTC, tm))
+	ts, err = p.GetTsync()
+	if err != nil || !ts.IsResp || !ts.Time.Equal(tm) {
+		t.Error(err, ts)
+	}
+	tm = time.Date(2000, time.February, 29, 0, 0, 0, 0, time.UTC)
+	p, _ = Parse(MkTsyncResp(TsyncUTC, tm))
+	ts, err = p.GetTsync()
+	if err != nil || !ts.Time.Equal(tm) {
+		t.Error(err, ts)
+	}
+	p, _ = Parse(MkDeFaultAllReq())
+	if _, err = p.GetTsync(); !errors.Is(err, ErrCmdId) {
+		t.Error(err)
+	}
+
+	p, _ = Parse(MkDeFaultAllReq())
+	def, err := p.GetDEFault()
+	if err != nil || def.Kind != DefKindReqAll {
+		t.Error(err, def)
+	}
+	p, _ = Parse(MkDeFaultNoneAll())
+	def, err = p.GetDEFault()
+	if err != nil || def.Kind != DefKindNoneAll {
+		t.Error(err, def)
+	}
+	p, _ = Parse(MkDeFaultAck(DegSensor, 4))
+	def, err = p.GetDEFault()
+	if err != nil || def.Kind != DefKindAck || def.Group != DegSensor || def.Id != 4 {
+		t.Error(err, def)
+	}
+	p, _ = Parse(MkDeFaultRep(DegControl, 5, DefUnstable))
+	def, err = p.GetDEFault()
+	if err != nil || def.Kind != DefKindReport || def.Group != DegControl || def.Id != 5 || def.Fault != DefUnstable {
+		t.Error(err, def)
+	}
+}
+
+func TestPgDEPList(t *testing.T) {
+	SetVer(0)
+	depList := []DePkt{
+		{Group: DegSensor, Id: 0, Dtype: DEtypeUint, Data: 1234},
+		{Group: DegSensor, Id: 1, Dtype: DEtypeString, Dlen: 4, DataRaw: []byte("warm")},
+		{Group: DegControl, Id: 2, Dtype: DEtypeBool, Data: 1},
+		{Group: DegControl, Id: 3, Dtype: DEtypeBmap2, Data: 0xA5A5},
+	}
+	for _, buf := range [][]byte{MkDESList(depList), MkDERList(depList)} {
+		t.Logf("multi DE: %x", buf)
+		p, err := Parse(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pList, err := p.GetDEPList()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pList) != len(depList) {
+			t.Fatalf("expected %d DE but got %d", len(depList), len(pList))
+		}
+		for i, dep := range pList {
+			if dep.Group != depList[i].Group || dep.Id != depList[i].Id || dep.Dtype != depList[i].Dtype ||
+				dep.Data != depList[i].Data || (dep.Dtype == DEtypeString && string(dep.DataRaw) != "warm") {
+				t.Errorf("DE %d: expected [%s] but got [%s]", i, depList[i], dep)
+			}
+		}
+	}
+
+	p, _ := Parse(MkDeResetAllReq())
+	pList, err := p.GetDEPList()
+	if err != nil || len(pList) != 0 {
+		t.Error(err, pList)
+	}
+
+	b := Create(CmdDEReport)
+	b.AppendDEP(depList[0])
+	b.Append([]byte{byte(DegSensor), 9, byte(DEtypeUint), 0})
+	p, _ = Parse(b.Build().Buf)
+	_, err = p.GetDEPList()
+	if !errors.Is(err, ErrTooShort) {
+		t.Error(err)
+	}
+	t.Log(err)
+}
+
+// Fixed-point DE data of values known to be representable
+func mustFixed(v float64, scale float32, offset float32) []byte {
+	data, err := FixedToBslice(v, scale, offset)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestPgDeNumeric(t *testing.T) {
+	SetVer(0)
+	cases := []struct {
+		buf  []byte
+		want float64
+		str  string
+	}{
+		{MkDeSetInt8(DegControl, 1, -5), -5, "data: -5"},
+		{MkDeRepInt16(DegSensor, 2, math.MinInt16), math.MinInt16, "data: -32768"},
+		{MkDeSetInt32(DegControl, 3, -100000), -100000, "data: -100000"},
+		{MkDeRepFloat(DegSensor, 4, 55.5), 55.5, "data: 55.5"},
+		{MkDeRepFixed(DegSensor, 5, -12.3, 0.1, -40), -12.3, "data: -12.3"},
+		{MkDeSetFixed(DegControl, 6, 21.5, 0.5, 0), 21.5, "data: 21.5"},
+		{MkDeRepFixed(DegSensor, 7, 123456.789, 0.001, 0), 123456.789, "data: 123456.789"},
+	}
+	for i, c := range cases {
+		p, err := Parse(c.buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dep, err := p.GetDEP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("numeric %d: %s", i, dep)
+		if got := DepFloatData(dep); math.Abs(got-c.want) > 1e-4 {
+			t.Errorf("numeric %d: expected %g but got %g", i, c.want, got)
+		}
+		if !strings.HasSuffix(dep.String(), c.str) {
+			t.Errorf("numeric %d: expected %q in %q", i, c.str, dep)
+		}
+	}
+
+	raw, scale, offset := DepFixedPoint(DePkt{Dtype: DEtypeFixed, DataRaw: mustFixed(-12.3, 0.1, -40)})
+	if raw != 277 || scale != 0.1 || offset != -40 {
+		t.Error(raw, scale, offset)
+	}
+	bad := []struct {
+		v             float64
+		scale, offset float32
+		raw           int32
+	}{
+		{1, 0, 1, 0},
+		{math.NaN(), 1, 0, 0},
+		{1e40, 1e-30, 0, math.MaxInt32},
+		{-1e40, 1e-30, 0, math.MinInt32},
+	}
+	for _, c := range bad {
+		raw, err := FixedRaw(c.v, c.scale, c.offset)
+		if !errors.Is(err, ErrInvalidData) || raw != c.raw {
+			t.Errorf("fixed %g/%g/%g: %d %v", c.v, c.scale, c.offset, raw, err)
+		}
+		if data, err := FixedToBslice(c.v, c.scale, c.offset); err == nil || len(data) != 0 {
+			t.Errorf("fixed %g/%g/%g: %x", c.v, c.scale, c.offset, data)
+		}
+	}
+	dep, _ := ParseDEP(MkDeSetFixed(DegControl, 1, 1e40, 1e-30, 0)[IdxData:])
+	if raw, _, _ := DepFixedPoint(dep); raw != math.MaxInt32 {
+		t.Error(dep)
+	}
+	for _, t2 := range []DEtype{DEtypeInt8, DEtypeInt16, DEtypeInt32, DEtypeFloat, DEtypeFixed} {
+		buf := []byte{byte(DegSensor), 1, byte(t2), 0, 3, 1, 2, 3}
+		if _, err := ParseDEP(buf); !errors.Is(err, ErrLenMismatch) {
+			t.Errorf("%s with dlen 3: %v", t2, err)
+		}
+	}
+
+	sch := []SchPkt{{Id: 1, Hour: 6, Dep: DePkt{Group: DegControl, Id: 6, Dtype: DEtypeFixed,
+		Dlen: LenDeFixed, DataRaw: mustFixed(19, 0.5, 0)}}}
+	p, _ := Parse(MkSchSet(sch))
+	pSch, err := p.GetSchList()
+	if err != nil || DepFloatData(pSch[0].Dep) != 19 {
+		t.Error(err, pSch)
+	}
+}
+
+func TestPgSch(t *testing.T) {
+	SetVer(0)
+	mk := func(data ...byte) BasePkt {
+		b := Create(CmdSchedule)
+		b.Append(data)
+		p, err := Parse(b.Build().Buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	p, _ := Parse(MkSchEraseAllReq())
+	if sch, err := p.GetSch(); err != nil || sch.Kind != SchKindEraseAll {
+		t.Error(sch, err)
+	}
+	p, _ = Parse(MkSchExecReport(7))
+	if sch, err := p.GetSch(); err != nil || sch.Kind != SchKindExecReport || sch.ExecId != 7 {
+		t.Error(sch, err)
+	}
+	if _, err := p.GetSchList(); !errors.Is(err, ErrSchedule) {
+		t.Error(err)
+	}
+	if _, err := mk().GetSchList(); !errors.Is(err, ErrSchedule) {
+		t.Error(err)
+	}
+	list := []SchPkt{
+		{Id: 1, Weekdays: 0b0111110, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Dlen: LenDeBool, Data: 1}},
+		{Id: 2, Weekdays: 0b1000001, Hour: 9, Minute: 0, Dep: DePkt{Group: DegControl, Id: 2, Dtype: DEtypeString, Dlen: 2, DataRaw: []byte("hi")}},
+	}
+	p, _ = Parse(MkSchSet(list))
+	if sch, err := p.GetSch(); err != nil || sch.Kind != SchKindSet || len(sch.List) != 2 || string(sch.List[1].Dep.DataRaw) != "hi" {
+		t.Error(sch, err)
+	}
+	// Empty set list is indistinguishable from execution report of schedule 0
+	if sch, err := mk(0).GetSch(); err != nil || sch.Kind != SchKindExecReport {
+		t.Error(sch, err)
+	}
+
+	set := p.Data
+	cases := []struct {
+		p   BasePkt
+		err error
+		idx string
+	}{
+		{mk(1, 1, 2, 3), ErrTooShort, "schedule 0 header at byte 7"},
+		{mk(2, 1, 2, 3, 4, 0, 1, 2, 0, 1, 1), ErrTooShort, "schedule 1 header at byte 17"},
+		{mk(1, 1, 2, 3, 4, 0, 1, 0, 0, 5), ErrLenMismatch, "schedule 0 DE dlen at byte 14"},
+		{mk(append(append([]byte{}, set...), 0)...), ErrLenMismatch, "trailing bytes after 2 schedules"},
+		{mk(append([]byte{3}, set[1:]...)...), ErrTooShort, "schedule 2 header"},
+		{mk(set[:len(set)-1]...), ErrLenMismatch, "schedule 1 DE dlen"},
+		{BasePkt{CommandID: CmdSchedule, DataLen: 1}, ErrLenMismatch, "dlen"},
+		{BasePkt{CommandID: CmdDESet}, ErrCmdId, ""},
+	}
+	for i, c := range cases {
+		_, err := c.p.GetSch()
+		t.Logf("sch %d: %v", i, err)
+		if !errors.Is(err, c.err) || !strings.Contains(fmt.Sprint(err), c.idx) {
+			t.Errorf("sch %d: expected %v with %q but got %v", i, c.err, c.idx, err)
+		}
+	}
+}
+
+func TestPgParseError(t *testing.T) {
+	SetVer(0)
+	good := MkDESList([]DePkt{
+		{Group: DegControl, Id: 1, Dtype: DEtypeBool, Dlen: LenDeBool, Data: 1},
+		{Group: DegControl, Id: 2, Dtype: DEtypeUint, Dlen: LenDeUint, Data: 42},
+	})
+	withChksum := func(buf []byte) []byte {
+		buf[len(buf)-1] = Chksum(buf[:len(buf)-1])
+		return buf
+	}
+	badType := append([]byte{}, good...)
+	badType[int(IdxData)+int(LenDePktMin)+int(LenDeBool)+int(IdxDEPtype)] = 0x7f
+	badType = withChksum(badType)
+	badDlen := append([]byte{}, good...)
+	badDlen[int(IdxData)+int(LenDePktMin)+int(LenDeBool)+int(IdxDEPdlen)+1] = 9
+	badDlen = withChksum(badDlen)
+	badChksum := append([]byte{}, good...)
+	badChksum[len(badChksum)-1]++
+	badTsync := MkTsyncResp(TsyncUTC, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	badTsync[int(IdxData)+int(IdxTsyncDate)] = 30
+	badTsync = withChksum(badTsync)
+
+	cases := []struct {
+		buf      []byte
+		sentinel error
+		cmd      CmdID
+		offset   int
+		field    string
+		expected any
+		actual   any
+	}{
+		{good[:3], ErrTooShort, 0, 0, "packet length", int(LenPktMin), 3},
+		{append([]byte{0x56}, good[1:]...), ErrInvalidData, 0, 0, "header 1", Head1, byte(0x56)},
+		{badChksum, ErrChksum, CmdDESet, len(good) - 1, "chksum", good[len(good)-1], good[len(good)-1] + 1},
+		{withChksum(append(append([]byte{}, good[:len(good)-1]...), 0, 0)), ErrLenMismatch, CmdDESet, int(IdxDlen), "dlen", len(good) - int(LenPktMin) + 1, len(good) - int(LenPktMin)},
+		{badDlen, ErrLenMismatch, CmdDESet, 6 + 6 + 3, "DE 1 dlen", 4, 9},
+		{badTsync, ErrInvalidData, CmdTimeSync, int(IdxData) + int(IdxTsyncDate), "time synchronization date", "1-29", 30},
+	}
+	for i, c := range cases {
+		p, err := Parse(c.buf)
+		if err == nil {
+			switch p.CommandID {
+			case CmdDESet:
+				_, err = p.GetDEPList()
+			case CmdTimeSync:
+				_, err = p.GetTsync()
+			}
+		}
+		t.Logf("parse error %d: %v", i, err)
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, c.sentinel) {
+			t.Errorf("parse error %d: expected ParseError wrapping %v but got %v", i, c.sentinel, err)
+			continue
+		}
+		if pe.HasCmd != (i >= 2) {
+			t.Errorf("parse error %d: command known %t in %v", i, pe.HasCmd, err)
+		}
+		if pe.Cmd != c.cmd || pe.Offset != c.offset || pe.Field != c.field || pe.Expected != c.expected || pe.Actual != c.actual {
+			t.Errorf("parse error %d: expected %v %d %q %v %v but got %+v", i, c.cmd, c.offset, c.field, c.expected, c.actual, pe)
+		}
+	}
+
+	// DE type unknown to this version is kept as raw bytes
+	p, _ := Parse(badType)
+	depList, err := p.GetDEPList()
+	if err != nil || len(depList) != 2 || depList[1].Dtype != 0x7f || !bytes.Equal(AppendDEP(nil, depList[1]), depList[1].Buf) {
+		t.Error(depList, err)
+	}
+
+	p, _ = Parse(MkSchSet([]SchPkt{{Id: 1, Weekdays: 1, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Dlen: LenDeBool}}}))
+	p.Data = p.Data[:len(p.Data)-1]
+	p.DataLen--
+	_, err = p.GetSchList()
+	var pe *ParseError
+	if !errors.Is(err, ErrSchedule) || !errors.As(err, &pe) || pe.Field != "schedule 0 DE dlen" {
+		t.Error(err)
+	}
+}
+
 func BenchmarkPgDe(b *testing.B) {
 
 	b.Run("PG", func(b *testing.B) {
+		b.ReportAllocs()
 		for i := 0; i < b.N; i++ {
 			MkDeSetStr(DegInfo, 1, "Benchmark")
 		}
 	})
 
+	b.Run("PGAppend", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 64)
+		for i := 0; i < b.N; i++ {
+			buf = AppendDeSetStr(buf[:0], DegInfo, 1, "Benchmark")
+		}
+	})
+
+	b.Run("PGAppendUint", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 64)
+		for i := 0; i < b.N; i++ {
+			buf = AppendDeRepUint(buf[:0], DegSensor, 1, uint32(i))
+		}
+	})
+
+	b.Run("PGAppendList", func(b *testing.B) {
+		b.ReportAllocs()
+		depList := []DePkt{
+			{Group: DegSensor, Id: 1, Dtype: DEtypeUint, Data: 42},
+			{Group: DegSensor, Id: 2, Dtype: DEtypeBool, Data: 1},
+			{Group: DegInfo, Id: 1, Dtype: DEtypeString, Dlen: 9, DataRaw: []byte("Benchmark")},
+		}
+		buf := make([]byte, 0, 64)
+		for i := 0; i < b.N; i++ {
+			buf = AppendDERList(buf[:0], depList)
+		}
+	})
+
 	b.Run("JSON", func(b *testing.B) {
 		var data struct {
 			Ver       byte    `json:"version"`
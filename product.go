@@ -0,0 +1,147 @@
+package pg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Product definition with device info strings and DE schema
+type Product struct {
+	Name   string
+	Info   map[DeviceInfoRB]string
+	Schema *Schema
+}
+
+// Device info keys of product definition file
+var productInfoKeys = map[string]DeviceInfoRB{
+	"uplink_dest": UplinkDest,
+	"device_type": DeviceType,
+	"device_name": DeviceName,
+	"device_id":   DeviceID,
+}
+
+// DE entry of product definition file
+type productDE struct {
+	Name   string          `json:"name"`
+	Group  string          `json:"group"`
+	Id     *int            `json:"id"`
+	Type   string          `json:"type"`
+	Unit   string          `json:"unit"`
+	Min    float64         `json:"min"`
+	Max    float64         `json:"max"`
+	MaxLen uint16          `json:"max_len"`
+	Scale  float32         `json:"scale"`
+	Offset float32         `json:"offset"`
+	Enum   map[byte]string `json:"enum"`
+	Bits   []string        `json:"bits"`
+	Access string          `json:"access"`
+}
+
+// Product definition file
+type productFile struct {
+	Product  string            `json:"product"`
+	Info     map[string]string `json:"info"`
+	Entities []productDE       `json:"entities"`
+}
+
+// Parse DE access as printed by its String method, empty means read-only
+func ParseDEAccess(s string) (DEAccess, error) {
+	switch strings.ToLower(s) {
+	case "", "r":
+		return AccessRead, nil
+	case "w":
+		return AccessWrite, nil
+	case "rw":
+		return AccessRW, nil
+	default:
+		return 0, fmt.Errorf("%w: DE access %q", ErrInvalidData, s)
+	}
+}
+
+// Load JSON product definition from r
+func LoadProduct(r io.Reader) (*Product, error) {
+	var f productFile
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+
+	prod := &Product{Name: f.Product, Info: map[DeviceInfoRB]string{}, Schema: NewSchema()}
+	for k, v := range f.Info {
+		rb, ok := productInfoKeys[k]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown device info %q", ErrSchema, k)
+		}
+		prod.Info[rb] = v
+	}
+	for i, e := range f.Entities {
+		def, err := e.def()
+		if err == nil {
+			err = prod.Schema.Add(def)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w on entity %d %q", err, i, e.Name)
+		}
+	}
+	return prod, nil
+}
+
+// Load JSON product definition file at path
+func LoadProductFile(path string) (*Product, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadProduct(f)
+}
+
+// Convert product definition file entry into DE definition
+func (e productDE) def() (DEDef, error) {
+	var err error
+	def := DEDef{Name: e.Name, Unit: e.Unit, Min: e.Min, Max: e.Max, MaxLen: e.MaxLen,
+		Scale: e.Scale, Offset: e.Offset, Enum: e.Enum, Bits: e.Bits}
+	if e.Name == "" {
+		return def, fmt.Errorf("%w: missing name", ErrSchema)
+	}
+	if e.Id == nil || *e.Id < 0 || *e.Id > 255 {
+		return def, fmt.Errorf("%w: missing or invalid id", ErrSchema)
+	}
+	def.Id = byte(*e.Id)
+	if def.Group, err = ParseDEGroup(e.Group); err != nil {
+		return def, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+	if def.Dtype, err = ParseDEtype(e.Type); err != nil {
+		return def, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+	if def.Access, err = ParseDEAccess(e.Access); err != nil {
+		return def, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+
+	if def.Dtype == DEtypeEnum && len(def.Enum) == 0 {
+		return def, fmt.Errorf("%w: enum without labels", ErrSchema)
+	}
+	if def.Dtype != DEtypeEnum && len(def.Enum) > 0 {
+		return def, fmt.Errorf("%w: enum labels on %s", ErrSchema, def.Dtype)
+	}
+	if BmapWidth(def.Dtype) == 0 && len(def.Bits) > 0 {
+		return def, fmt.Errorf("%w: bit names on %s", ErrSchema, def.Dtype)
+	}
+	if def.Min > def.Max {
+		return def, fmt.Errorf("%w: min %g above max %g", ErrSchema, def.Min, def.Max)
+	}
+	if def.Dtype == DEtypeFixed && def.Scale == 0 {
+		return def, fmt.Errorf("%w: fixed without scale", ErrSchema)
+	}
+	if def.Dtype != DEtypeFixed && (def.Scale != 0 || def.Offset != 0) {
+		return def, fmt.Errorf("%w: scale or offset on %s", ErrSchema, def.Dtype)
+	}
+	if def.MaxLen > 0 && def.Dtype != DEtypeRaw && def.Dtype != DEtypeString {
+		return def, fmt.Errorf("%w: max_len on %s", ErrSchema, def.Dtype)
+	}
+	return def, nil
+}
@@ -0,0 +1,46 @@
+package pg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadProduct(t *testing.T) {
+	prod, err := LoadProductFile("testdata/fan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.Name != "Fan" || prod.Info[DeviceName] != "Ceiling Fan" {
+		t.Error(prod.Name, prod.Info)
+	}
+	def, ok := prod.Schema.ByName("Mode")
+	if !ok || def.Group != DegControl || def.Id != 2 || def.Enum[1] != "Breeze" || def.Access != AccessRW {
+		t.Error(def)
+	}
+	if _, err = prod.Schema.Check(MkDeSetFixed(DegSensor, 1, 20, 0.1, -40)); !errors.Is(err, ErrDEReadOnly) {
+		t.Error(err)
+	}
+
+	bad := []string{
+		`{"entities": [{"name": "A", "group": "control", "id": 1, "type": "bool"}, {"name": "B", "group": "control", "id": 1, "type": "bool"}]}`,
+		`{"entities": [{"name": "A", "group": "control", "id": 1, "type": "float128"}]}`,
+		`{"entities": [{"name": "A", "group": "control", "id": 1, "type": "enum"}]}`,
+		`{"entities": [{"name": "A", "group": "control", "id": 1, "type": "bmap1", "bits": ["0","1","2","3","4","5","6","7","8"]}]}`,
+		`{"entities": [{"name": "A", "group": "control", "id": 1, "type": "uint", "bits": ["0"]}]}`,
+		`{"entities": [{"name": "A", "group": "sensor", "id": 1, "type": "fixed"}]}`,
+		`{"entities": [{"name": "A", "group": "sensor", "id": 1, "type": "int16", "scale": 0.5}]}`,
+		`{"entities": [{"name": "A", "group": "control", "type": "uint"}]}`,
+		`{"entities": [{"name": "A", "group": "actuator", "id": 1, "type": "uint"}]}`,
+		`{"entities": [{"name": "A", "group": "control", "id": 1, "type": "uint", "min": 5, "max": 1}]}`,
+		`{"info": {"device_colour": "red"}}`,
+		`{"entity": []}`,
+	}
+	for i, b := range bad {
+		_, err = LoadProduct(strings.NewReader(b))
+		if !errors.Is(err, ErrSchema) {
+			t.Errorf("definition %d: expected schema error but got %v", i, err)
+		}
+		t.Log(err)
+	}
+}
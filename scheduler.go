@@ -0,0 +1,313 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Weekdays byte // Schedule weekday bitmask, bit n is time.Weekday n
+const (
+	WeekdaysAll      Weekdays = 0x7f // Every day of the week
+	WeekdaysReserved Weekdays = 0x80 // Bit 7, not a weekday
+)
+
+var weekdayNames = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// Make weekday bitmask containing days
+func NewWeekdays(days []time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		if d >= time.Sunday && d <= time.Saturday {
+			w |= 1 << d
+		}
+	}
+	return w
+}
+
+// Parse comma separated weekday names or ranges such as "Mon-Fri,Sun"
+// Names are matched case-insensitively by their first 3 letters or in full
+func ParseWeekdays(s string) (Weekdays, error) {
+	var w Weekdays
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(item), "-")
+		first, err := parseWeekday(from)
+		if err != nil {
+			return 0, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseWeekday(to); err != nil {
+				return 0, err
+			}
+		}
+		// Ranges may wrap around the end of the week such as "Sat-Mon"
+		for d := first; ; d = (d + 1) % 7 {
+			w |= 1 << d
+			if d == last {
+				break
+			}
+		}
+	}
+	return w, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.TrimSpace(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, weekdayNames[d]) || strings.EqualFold(s, d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: weekday %q", ErrInvalidData, s)
+}
+
+// Whether bitmask contains weekday wd
+func (w Weekdays) Contains(wd time.Weekday) bool {
+	return wd >= time.Sunday && wd <= time.Saturday && w&(1<<wd) != 0
+}
+
+// Weekdays contained in bitmask from Sunday to Saturday
+func (w Weekdays) Days() []time.Weekday {
+	days := []time.Weekday{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if w.Contains(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func (w Weekdays) String() string {
+	names := []string{}
+	for _, d := range w.Days() {
+		names = append(names, weekdayNames[d])
+	}
+	if w&WeekdaysReserved != 0 {
+		names = append(names, "Bit7")
+	}
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Join(names, ",")
+}
+
+// Weekdays bitmask of schedule
+func (p SchPkt) Wdays() Weekdays {
+	return Weekdays(p.Weekdays)
+}
+
+// Whether schedule is set to run on weekday wd
+func (p SchPkt) RunsOn(wd time.Weekday) bool {
+	return p.Wdays().Contains(wd)
+}
+
+// Check schedule time and weekdays
+func (p SchPkt) Validate() error {
+	if p.Hour > 23 || p.Minute > 59 {
+		return fmt.Errorf("%w: time %02d:%02d on schedule id %d", ErrSchedule, p.Hour, p.Minute, p.Id)
+	}
+	if p.Wdays()&WeekdaysReserved != 0 {
+		return fmt.Errorf("%w: weekdays bit 7 set on schedule id %d", ErrSchedule, p.Id)
+	}
+	return nil
+}
+
+// Check every schedule of schList, reject duplicate ids and
+// schedules writing the same DE at the same minute of the same weekday
+func ValidateSchList(schList []SchPkt) error {
+	ids := map[byte]int{}
+	for i, sch := range schList {
+		if err := sch.Validate(); err != nil {
+			return fmt.Errorf("%w on schedule index %d", err, i)
+		}
+		if j, ok := ids[sch.Id]; ok {
+			return fmt.Errorf("%w: id %d on schedule index %d and %d", ErrSchedule, sch.Id, j, i)
+		}
+		ids[sch.Id] = i
+		for _, other := range schList[:i] {
+			if other.Dep.Group == sch.Dep.Group && other.Dep.Id == sch.Dep.Id &&
+				other.Hour == sch.Hour && other.Minute == sch.Minute && other.Wdays()&sch.Wdays()&WeekdaysAll != 0 {
+				return fmt.Errorf("%w: schedule id %d and %d both write %s/%d at %02d:%02d on %s", ErrSchedule,
+					other.Id, sch.Id, sch.Dep.Group, sch.Dep.Id, sch.Hour, sch.Minute, other.Wdays()&sch.Wdays()&WeekdaysAll)
+			}
+		}
+	}
+	return nil
+}
+
+// Next firing time of schedule strictly after t, in the location of t
+// Returns false when schedule never fires
+func (p SchPkt) Next(t time.Time) (time.Time, bool) {
+	if p.Wdays()&WeekdaysAll == 0 || p.Hour > 23 || p.Minute > 59 {
+		return time.Time{}, false
+	}
+	y, m, d := t.Date()
+	for i := 0; i <= 7; i++ {
+		next := time.Date(y, m, d+i, int(p.Hour), int(p.Minute), 0, 0, t.Location())
+		if next.After(t) && p.RunsOn(next.Weekday()) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Scheduler configuration
+type SchedulerConfig struct {
+	Location *time.Location         // Time zone of schedule hour and minute, nil means time.Local
+	Now      func() time.Time       // Clock, nil means time.Now
+	Apply    func(sch SchPkt)       // Applies Dep of fired schedule
+	Report   func(buf []byte) error // Sends schedule execution report packet, nil skips reports
+}
+
+// Schedule execution engine firing schedules at their weekday, hour and minute
+type Scheduler struct {
+	cfg SchedulerConfig
+
+	mu    sync.Mutex
+	sch   []SchPkt
+	fired map[byte]time.Time // Minute each schedule id last fired
+	wake  chan struct{}
+}
+
+// Create scheduler with no schedules
+func NewScheduler(cfg SchedulerConfig) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Scheduler{cfg: cfg, fired: map[byte]time.Time{}, wake: make(chan struct{}, 1)}
+}
+
+// Replace all schedules with schList
+func (s *Scheduler) Set(schList []SchPkt) {
+	s.mu.Lock()
+	s.sch = make([]SchPkt, len(schList))
+	for i, sch := range schList {
+		sch.Dep = cloneDEP(sch.Dep)
+		s.sch[i] = sch
+	}
+	s.fired = map[byte]time.Time{}
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Erase all schedules
+func (s *Scheduler) Clear() {
+	s.Set(nil)
+}
+
+// Stored schedules
+func (s *Scheduler) Schedules() []SchPkt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SchPkt{}, s.sch...)
+}
+
+// Store schedules of schedule set packet or erase all on schedule erase packet
+// Schedule execution reports are ignored
+func (s *Scheduler) Ingest(p BasePkt) error {
+	sch, err := p.GetSch()
+	if err != nil {
+		return err
+	}
+	switch sch.Kind {
+	case SchKindEraseAll:
+		s.Clear()
+	case SchKindSet:
+		s.Set(sch.List)
+	}
+	return nil
+}
+
+// Next firing time after now and the schedules firing at that time
+// Returns false when no schedule fires
+func (s *Scheduler) Next() (time.Time, []SchPkt, bool) {
+	now := s.cfg.Now().In(s.cfg.Location)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first time.Time
+	due := []SchPkt{}
+	for _, sch := range s.sch {
+		next, ok := sch.Next(now)
+		if !ok {
+			continue
+		}
+		if len(due) == 0 || next.Before(first) {
+			first = next
+			due = due[:0]
+		}
+		if next.Equal(first) {
+			due = append(due, sch)
+		}
+	}
+	return first, due, len(due) > 0
+}
+
+// Fire every schedule due at the current minute that has not fired in it yet
+// Returns fired schedules ordered by id
+func (s *Scheduler) Tick() []SchPkt {
+	now := s.cfg.Now().In(s.cfg.Location)
+	minute := now.Truncate(time.Minute)
+	s.mu.Lock()
+	due := []SchPkt{}
+	for _, sch := range s.sch {
+		// Due when this minute is its next firing time, so times shifted by DST fire when Next says
+		if next, ok := sch.Next(minute.Add(-time.Nanosecond)); !ok || !next.Equal(minute) {
+			continue
+		}
+		if last, ok := s.fired[sch.Id]; ok && last.Equal(minute) {
+			continue
+		}
+		s.fired[sch.Id] = minute
+		due = append(due, sch)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Id < due[j].Id })
+	for _, sch := range due {
+		if s.cfg.Apply != nil {
+			s.cfg.Apply(sch)
+		}
+		if s.cfg.Report != nil {
+			s.cfg.Report(MkSchExecReport(sch.Id))
+		}
+	}
+	return due
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Fire schedules on time until ctx is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if next, _, ok := s.Next(); ok {
+			timer = time.NewTimer(next.Sub(s.cfg.Now()))
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-timeout:
+			s.Tick()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
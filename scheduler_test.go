@@ -0,0 +1,230 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchNext(t *testing.T) {
+	loc := time.FixedZone("UTC+7", 7*3600)
+	sch := SchPkt{Id: 1, Weekdays: 1<<time.Monday | 1<<time.Wednesday, Hour: 7, Minute: 30}
+
+	// Sunday 2024-03-03
+	tm := time.Date(2024, 3, 3, 12, 0, 0, 0, loc)
+	next, ok := sch.Next(tm)
+	if !ok || !next.Equal(time.Date(2024, 3, 4, 7, 30, 0, 0, loc)) {
+		t.Error(next, ok)
+	}
+	next, ok = sch.Next(next)
+	if !ok || !next.Equal(time.Date(2024, 3, 6, 7, 30, 0, 0, loc)) {
+		t.Error(next, ok)
+	}
+	next, ok = sch.Next(next)
+	if !ok || !next.Equal(time.Date(2024, 3, 11, 7, 30, 0, 0, loc)) {
+		t.Error(next, ok)
+	}
+
+	for _, bad := range []SchPkt{{Weekdays: 0x80}, {Weekdays: 1, Hour: 24}, {Weekdays: 1, Minute: 60}} {
+		if _, ok = bad.Next(tm); ok {
+			t.Error(bad)
+		}
+	}
+}
+
+func TestWeekdays(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Weekdays
+		str  string
+	}{
+		{"Mon-Fri", 0b0111110, "Mon,Tue,Wed,Thu,Fri"},
+		{"mon,wed", 0b0001010, "Mon,Wed"},
+		{"Sat-Mon", 0b1000011, "Sun,Mon,Sat"},
+		{"Sunday, Tuesday", 0b0000101, "Sun,Tue"},
+		{"Sun-Sat", WeekdaysAll, "Sun,Mon,Tue,Wed,Thu,Fri,Sat"},
+	}
+	for _, c := range cases {
+		w, err := ParseWeekdays(c.in)
+		if err != nil || w != c.want || w.String() != c.str {
+			t.Errorf("%q: expected %07b %s but got %07b %s %v", c.in, c.want, c.str, w, w, err)
+		}
+	}
+	for _, bad := range []string{"", "Mon-", "Funday", "Mon,,Tue"} {
+		if _, err := ParseWeekdays(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("%q: %v", bad, err)
+		}
+	}
+
+	w := NewWeekdays([]time.Weekday{time.Saturday, time.Monday, 9})
+	if w != 0b1000010 || !w.Contains(time.Saturday) || w.Contains(time.Sunday) || w.Contains(7) {
+		t.Errorf("%07b", w)
+	}
+	if days := w.Days(); len(days) != 2 || days[0] != time.Monday || days[1] != time.Saturday {
+		t.Error(days)
+	}
+	if s := (WeekdaysReserved | 1).String(); s != "Sun,Bit7" {
+		t.Error(s)
+	}
+	if s := Weekdays(0).String(); s != "None" {
+		t.Error(s)
+	}
+}
+
+func TestValidateSchList(t *testing.T) {
+	dep := DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1}
+	other := DePkt{Group: DegControl, Id: 2, Dtype: DEtypeBool, Data: 1}
+	ok := []SchPkt{
+		{Id: 1, Weekdays: 0b0111110, Hour: 7, Minute: 30, Dep: dep},
+		{Id: 2, Weekdays: 0b1000001, Hour: 7, Minute: 30, Dep: dep},
+		{Id: 3, Weekdays: 0b0111110, Hour: 7, Minute: 30, Dep: other},
+		{Id: 4, Weekdays: 0b0111110, Hour: 23, Minute: 59, Dep: dep},
+	}
+	if err := ValidateSchList(ok); err != nil {
+		t.Error(err)
+	}
+
+	bad := [][]SchPkt{
+		{{Id: 1, Weekdays: 1, Hour: 24, Dep: dep}},
+		{{Id: 1, Weekdays: 1, Minute: 60, Dep: dep}},
+		{{Id: 1, Weekdays: byte(WeekdaysReserved | 1), Dep: dep}},
+		{{Id: 1, Weekdays: 1, Dep: dep}, {Id: 1, Weekdays: 1, Hour: 1, Dep: other}},
+		{{Id: 1, Weekdays: 0b0000011, Hour: 6, Dep: dep}, {Id: 2, Weekdays: 0b0000110, Hour: 6, Dep: dep}},
+	}
+	for i, schList := range bad {
+		err := ValidateSchList(schList)
+		t.Logf("bad %d: %v", i, err)
+		if !errors.Is(err, ErrSchedule) {
+			t.Errorf("bad %d: expected ErrSchedule but got %v", i, err)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	SetVer(0)
+	loc := time.FixedZone("UTC-5", -5*3600)
+	tm := time.Date(2024, 3, 4, 7, 0, 0, 0, loc) // Monday
+	applied := []DePkt{}
+	reports := [][]byte{}
+	s := NewScheduler(SchedulerConfig{
+		Location: loc,
+		Now:      func() time.Time { return tm },
+		Apply:    func(sch SchPkt) { applied = append(applied, sch.Dep) },
+		Report:   func(buf []byte) error { reports = append(reports, buf); return nil },
+	})
+
+	weekdays := byte(NewWeekdays([]time.Weekday{time.Monday, time.Tuesday}))
+	p, err := Parse(MkSchSet([]SchPkt{
+		{Id: 2, Weekdays: weekdays, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1}},
+		{Id: 1, Weekdays: weekdays, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 2, Dtype: DEtypeEnum, Data: 3}},
+		{Id: 3, Weekdays: 1 << time.Sunday, Hour: 9, Minute: 0, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Ingest(p); err != nil {
+		t.Fatal(err)
+	}
+
+	next, due, ok := s.Next()
+	if !ok || !next.Equal(time.Date(2024, 3, 4, 7, 30, 0, 0, loc)) || len(due) != 2 {
+		t.Error(next, due, ok)
+	}
+	if fired := s.Tick(); len(fired) != 0 {
+		t.Error(fired)
+	}
+
+	// Same UTC instant seen in another zone still fires on local time
+	tm = next.UTC().Add(10 * time.Second)
+	fired := s.Tick()
+	if len(fired) != 2 || fired[0].Id != 1 || fired[1].Id != 2 || len(applied) != 2 || applied[0].Data != 3 {
+		t.Error(fired, applied)
+	}
+	if len(reports) != 2 {
+		t.Fatal(reports)
+	}
+	rep, _ := Parse(reports[0])
+	if rep.CommandID != CmdSchedule || rep.DataLen != 1 || rep.Data[0] != 1 {
+		t.Error(rep)
+	}
+
+	// Already fired in this minute
+	tm = tm.Add(30 * time.Second)
+	if fired = s.Tick(); len(fired) != 0 {
+		t.Error(fired)
+	}
+
+	next, due, ok = s.Next()
+	if !ok || !next.Equal(time.Date(2024, 3, 5, 7, 30, 0, 0, loc)) || len(due) != 2 {
+		t.Error(next, due, ok)
+	}
+
+	p, _ = Parse(MkSchEraseAllReq())
+	if err = s.Ingest(p); err != nil || len(s.Schedules()) != 0 {
+		t.Error(err, s.Schedules())
+	}
+	if _, _, ok = s.Next(); ok {
+		t.Error("next without schedules")
+	}
+	p, _ = Parse(MkHandshake(nil))
+	if err = s.Ingest(p); err != ErrCmdId {
+		t.Error(err)
+	}
+}
+
+func TestSchedulerDSTGap(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 02:30 does not exist on Sunday 2024-03-10 and is normalized by time.Date to another hour
+	tm := time.Date(2024, 3, 10, 0, 0, 0, 0, loc)
+	s := NewScheduler(SchedulerConfig{Location: loc, Now: func() time.Time { return tm }})
+	s.Set([]SchPkt{{Id: 1, Weekdays: 1 << time.Sunday, Hour: 2, Minute: 30}})
+	next, _, ok := s.Next()
+	if !ok || next.Day() != 10 || next.Hour() == 2 {
+		t.Fatal(next, ok)
+	}
+	tm = next.Add(time.Second)
+	if fired := s.Tick(); len(fired) != 1 {
+		t.Error(fired)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	SetVer(0)
+	// Clock running from just before Monday 07:30
+	base := time.Date(2024, 3, 4, 7, 29, 59, int(900*time.Millisecond), time.UTC)
+	start := time.Now()
+	applied := make(chan SchPkt, 1)
+	s := NewScheduler(SchedulerConfig{
+		Location: time.UTC,
+		Now:      func() time.Time { return base.Add(time.Since(start)) },
+		Apply:    func(sch SchPkt) { applied <- sch },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	// Run waits without schedules until Set wakes it up
+	s.Set([]SchPkt{{Id: 4, Weekdays: 1 << time.Monday, Hour: 7, Minute: 30}})
+	select {
+	case sch := <-applied:
+		if sch.Id != 4 {
+			t.Error(sch)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("schedule did not fire")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Error(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("run did not return after cancel")
+	}
+}
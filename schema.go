@@ -0,0 +1,245 @@
+package pg
+
+import (
+	"fmt"
+	"sort"
+)
+
+type DEAccess byte // Data Entity access
+const (
+	AccessRead DEAccess = 1 << iota
+	AccessWrite
+	AccessRW = AccessRead | AccessWrite
+)
+
+func (a DEAccess) String() string {
+	switch a {
+	case AccessRead:
+		return "r"
+	case AccessWrite:
+		return "w"
+	case AccessRW:
+		return "rw"
+	default:
+		return "Invalid"
+	}
+}
+
+// Data Entity definition
+type DEDef struct {
+	Name   string
+	Group  DEGroup
+	Id     byte
+	Dtype  DEtype
+	Unit   string
+	Min    float64         // Lowest value, checked when Max > Min
+	Max    float64         // Highest value, checked when Max > Min
+	MaxLen uint16          // Longest Raw or String data, 0 means unlimited
+	Scale  float32         // Fixed-point scale, checked when not 0
+	Offset float32         // Fixed-point offset, checked when Scale is not 0
+	Enum   map[byte]string // Enumeration value names
+	Bits   []string        // Bitmap bit names, index is bit number
+	Access DEAccess
+}
+
+// Bit width of bitmap DE types, 0 for other types
+func BmapWidth(t DEtype) int {
+	switch t {
+	case DEtypeBmap1:
+		return 8
+	case DEtypeBmap2:
+		return 16
+	case DEtypeBmap4:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// Name of enumeration value v
+func (d DEDef) EnumName(v byte) (string, bool) {
+	name, ok := d.Enum[v]
+	return name, ok
+}
+
+// Names of bits set in bitmap value v
+func (d DEDef) BitNames(v uint32) []string {
+	names := []string{}
+	for i, name := range d.Bits {
+		if v&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Check DE packet value against definition
+func (d DEDef) Validate(dep DePkt) error {
+	if dep.Dtype != d.Dtype {
+		return fmt.Errorf("%w: %s expects type %s but got %s", ErrDEValue, d.Name, d.Dtype, dep.Dtype)
+	}
+	switch d.Dtype {
+	case DEtypeRaw, DEtypeString:
+		if d.MaxLen > 0 && dep.Dlen > d.MaxLen {
+			return fmt.Errorf("%w: %s length %d exceeds %d", ErrDEValue, d.Name, dep.Dlen, d.MaxLen)
+		}
+	case DEtypeBool:
+		if dep.Data > 1 {
+			return fmt.Errorf("%w: %s boolean value %d", ErrDEValue, d.Name, dep.Data)
+		}
+	case DEtypeEnum:
+		if _, ok := d.Enum[byte(dep.Data)]; len(d.Enum) > 0 && !ok {
+			return fmt.Errorf("%w: %s enumeration value %d", ErrDEValue, d.Name, dep.Data)
+		}
+	case DEtypeUint, DEtypeInt8, DEtypeInt16, DEtypeInt32, DEtypeFloat, DEtypeFixed:
+		if d.Dtype == DEtypeFixed && d.Scale != 0 {
+			if _, scale, offset := DepFixedPoint(dep); scale != d.Scale || offset != d.Offset {
+				return fmt.Errorf("%w: %s scale %g offset %g, expected %g %g", ErrDEValue, d.Name, scale, offset, d.Scale, d.Offset)
+			}
+		}
+		v := DepFloatData(dep)
+		if d.Max > d.Min && (v < d.Min || v > d.Max) {
+			return fmt.Errorf("%w: %s value %g outside %g-%g", ErrDEValue, d.Name, v, d.Min, d.Max)
+		}
+	case DEtypeBmap1, DEtypeBmap2, DEtypeBmap4:
+		if len(d.Bits) == 0 {
+			break
+		}
+		named := uint32(1)<<len(d.Bits) - 1
+		if dep.Data&^named != 0 {
+			return fmt.Errorf("%w: %s bitmap 0x%x sets unnamed bits", ErrDEValue, d.Name, dep.Data)
+		}
+	}
+	return nil
+}
+
+// Data Entity schema registry
+type Schema struct {
+	defs   map[deKey]DEDef
+	byName map[string]deKey
+}
+
+// Create empty schema
+func NewSchema() *Schema {
+	return &Schema{defs: map[deKey]DEDef{}, byName: map[string]deKey{}}
+}
+
+// Register DE definition
+func (s *Schema) Add(def DEDef) error {
+	k := deKey{def.Group, def.Id}
+	if old, ok := s.defs[k]; ok {
+		return fmt.Errorf("%w: %s/%d already defined as %s", ErrSchema, def.Group, def.Id, old.Name)
+	}
+	if _, ok := s.byName[def.Name]; ok && def.Name != "" {
+		return fmt.Errorf("%w: name %s already defined", ErrSchema, def.Name)
+	}
+	if w := BmapWidth(def.Dtype); len(def.Bits) > w {
+		return fmt.Errorf("%w: %s has %d bit names but %s is %d bits wide", ErrSchema, def.Name, len(def.Bits), def.Dtype, w)
+	}
+	s.defs[k] = def
+	if def.Name != "" {
+		s.byName[def.Name] = k
+	}
+	return nil
+}
+
+// Get definition of DE g/id
+func (s *Schema) Lookup(g DEGroup, id byte) (DEDef, bool) {
+	def, ok := s.defs[deKey{g, id}]
+	return def, ok
+}
+
+// Get definition of DE by name
+func (s *Schema) ByName(name string) (DEDef, bool) {
+	k, ok := s.byName[name]
+	if !ok {
+		return DEDef{}, false
+	}
+	return s.defs[k], true
+}
+
+// All definitions ordered by group then id
+func (s *Schema) Defs() []DEDef {
+	defs := make([]DEDef, 0, len(s.defs))
+	for _, def := range s.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Group != defs[j].Group {
+			return defs[i].Group < defs[j].Group
+		}
+		return defs[i].Id < defs[j].Id
+	})
+	return defs
+}
+
+// Check DE packet against its definition
+func (s *Schema) Validate(dep DePkt) error {
+	def, ok := s.Lookup(dep.Group, dep.Id)
+	if !ok {
+		return fmt.Errorf("%w: %s/%d", ErrDEUnknown, dep.Group, dep.Id)
+	}
+	return def.Validate(dep)
+}
+
+// Check DE packet against its definition and reject read-only DE
+func (s *Schema) ValidateSet(dep DePkt) error {
+	if err := s.Validate(dep); err != nil {
+		return err
+	}
+	if def, _ := s.Lookup(dep.Group, dep.Id); def.Access&AccessWrite == 0 {
+		return fmt.Errorf("%w: %s", ErrDEReadOnly, def.Name)
+	}
+	return nil
+}
+
+// Get all DE packets from DE set or report packet, validating each against schema
+func (s *Schema) GetDEPList(p BasePkt) ([]DePkt, error) {
+	depList, err := p.GetDEPList()
+	if err != nil {
+		return depList, err
+	}
+	validate := s.Validate
+	if p.CommandID == CmdDESet {
+		validate = s.ValidateSet
+	}
+	for i, dep := range depList {
+		if err = validate(dep); err != nil {
+			return []DePkt{}, fmt.Errorf("%w on DE index %d", err, i)
+		}
+	}
+	return depList, nil
+}
+
+// Validate built DE set or report packet such as the output of MkDeSet* and MkDeRep* helpers
+// Returns buf unchanged when every DE satisfies schema
+func (s *Schema) Check(buf []byte) ([]byte, error) {
+	p, err := Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.GetDEPList(p); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Make DE set packet containing all DE in depList after validating them
+func (s *Schema) MkDESList(depList []DePkt) ([]byte, error) {
+	for i, dep := range depList {
+		if err := s.ValidateSet(dep); err != nil {
+			return nil, fmt.Errorf("%w on DE index %d", err, i)
+		}
+	}
+	return MkDESList(depList), nil
+}
+
+// Make DE report packet containing all DE in depList after validating them
+func (s *Schema) MkDERList(depList []DePkt) ([]byte, error) {
+	for i, dep := range depList {
+		if err := s.Validate(dep); err != nil {
+			return nil, fmt.Errorf("%w on DE index %d", err, i)
+		}
+	}
+	return MkDERList(depList), nil
+}
@@ -0,0 +1,86 @@
+package pg
+
+import (
+	"errors"
+	"testing"
+)
+
+func testSchema(t *testing.T) *Schema {
+	s := NewSchema()
+	defs := []DEDef{
+		{Name: "Power", Group: DegControl, Id: 1, Dtype: DEtypeBool, Access: AccessRW},
+		{Name: "Mode", Group: DegControl, Id: 2, Dtype: DEtypeEnum, Access: AccessRW,
+			Enum: map[byte]string{0: "Auto", 1: "Cool", 2: "Heat"}},
+		{Name: "FanSpeed", Group: DegControl, Id: 7, Dtype: DEtypeUint, Unit: "rpm", Min: 0, Max: 3000, Access: AccessRW},
+		{Name: "Alarms", Group: DegSensor, Id: 3, Dtype: DEtypeBmap1, Access: AccessRead,
+			Bits: []string{"Overheat", "Filter", "Door"}},
+		{Name: "Serial", Group: DegInfo, Id: 0, Dtype: DEtypeString, MaxLen: 8, Access: AccessRead},
+		{Name: "Temperature", Group: DegSensor, Id: 1, Dtype: DEtypeFixed, Scale: 0.1, Offset: -40,
+			Min: -40, Max: 125, Access: AccessRead},
+		{Name: "Tilt", Group: DegSensor, Id: 4, Dtype: DEtypeInt8, Min: -90, Max: 90, Access: AccessRead},
+	}
+	for _, def := range defs {
+		if err := s.Add(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestSchema(t *testing.T) {
+	SetVer(0)
+	s := testSchema(t)
+	if err := s.Add(DEDef{Name: "Dup", Group: DegControl, Id: 7, Dtype: DEtypeUint}); !errors.Is(err, ErrSchema) {
+		t.Error(err)
+	}
+	if err := s.Add(DEDef{Name: "Wide", Group: DegSensor, Id: 9, Dtype: DEtypeBmap1, Bits: make([]string, 9)}); !errors.Is(err, ErrSchema) {
+		t.Error(err)
+	}
+	if def, ok := s.ByName("FanSpeed"); !ok || def.Unit != "rpm" {
+		t.Error(def)
+	}
+	if defs := s.Defs(); len(defs) != 7 || defs[0].Name != "Serial" {
+		t.Error(defs)
+	}
+
+	checks := []struct {
+		buf []byte
+		err error
+	}{
+		{MkDeSetUint(DegControl, 7, 1200), nil},
+		{MkDeSetUint(DegControl, 7, 3001), ErrDEValue},
+		{MkDeSetEnum(DegControl, 2, 2), nil},
+		{MkDeSetEnum(DegControl, 2, 3), ErrDEValue},
+		{MkDeSetBool(DegControl, 7, true), ErrDEValue},
+		{MkDeSetUint(DegControl, 8, 1), ErrDEUnknown},
+		{MkDeSetBmap1(DegSensor, 3, 0b101), ErrDEReadOnly},
+		{MkDeRepBmap1(DegSensor, 3, 0b101), nil},
+		{MkDeRepBmap1(DegSensor, 3, 0b1000), ErrDEValue},
+		{MkDeRepStr(DegInfo, 0, "SN123456"), nil},
+		{MkDeRepStr(DegInfo, 0, "SN1234567"), ErrDEValue},
+		{MkDeRepFixed(DegSensor, 1, -12.5, 0.1, -40), nil},
+		{MkDeRepFixed(DegSensor, 1, 130, 0.1, -40), ErrDEValue},
+		{MkDeRepFixed(DegSensor, 1, 20, 0.5, 0), ErrDEValue},
+		{MkDeRepInt8(DegSensor, 4, -45), nil},
+		{MkDeRepInt8(DegSensor, 4, -91), ErrDEValue},
+	}
+	for i, c := range checks {
+		_, err := s.Check(c.buf)
+		if (c.err == nil && err != nil) || !errors.Is(err, c.err) {
+			t.Errorf("check %d: expected %v but got %v", i, c.err, err)
+		}
+	}
+
+	def, _ := s.Lookup(DegSensor, 3)
+	if names := def.BitNames(0b101); len(names) != 2 || names[1] != "Door" {
+		t.Error(names)
+	}
+	_, err := s.MkDESList([]DePkt{
+		{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1},
+		{Group: DegInfo, Id: 0, Dtype: DEtypeString, Dlen: 2, DataRaw: []byte("SN")},
+	})
+	if !errors.Is(err, ErrDEReadOnly) {
+		t.Error(err)
+	}
+	t.Log(err)
+}
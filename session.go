@@ -0,0 +1,328 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Response matcher
+type Matcher func(p BasePkt) bool
+
+// Match any packet with cid as Command ID
+func MatchCmd(cid CmdID) Matcher {
+	return func(p BasePkt) bool {
+		return p.CommandID == cid
+	}
+}
+
+// Match packet with cid as Command ID and sub as its first data byte
+func MatchSub(cid CmdID, sub byte) Matcher {
+	return func(p BasePkt) bool {
+		return p.CommandID == cid && len(p.Data) > 0 && p.Data[0] == sub
+	}
+}
+
+// Match packet with cid as Command ID and dlen as its data length
+func MatchLen(cid CmdID, dlen uint16) Matcher {
+	return func(p BasePkt) bool {
+		return p.CommandID == cid && p.DataLen == dlen
+	}
+}
+
+// Session configuration
+type SessionConfig struct {
+	Ver         byte          // pg version of sent packets
+	Timeout     time.Duration // Response timeout of each attempt, 0 waits until context is done
+	Retries     int           // Resend count after response timeout
+	Unsolicited func(BasePkt) // Called on reader goroutine for packets not matching any request
+}
+
+// Default session configuration
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{Ver: PgVer, Timeout: time.Second, Retries: 2}
+}
+
+type sessionWaiter struct {
+	match Matcher
+	ch    chan BasePkt
+}
+
+type sessionSub struct {
+	match Matcher
+	ch    chan BasePkt
+}
+
+// Request/response session over a pg link
+type Session struct {
+	rw  io.ReadWriter
+	cfg SessionConfig
+	enc *Encoder
+	dec *Decoder
+
+	wmu     sync.Mutex
+	mu      sync.Mutex
+	waiters []*sessionWaiter
+	subs    []*sessionSub
+	subDrop uint64
+	done    chan struct{}
+	err     error
+}
+
+// Create session over rw using default session configuration
+func NewSession(rw io.ReadWriter) *Session {
+	return NewSessionConfig(rw, DefaultSessionConfig())
+}
+
+// Create session over rw with cfg as configuration
+// Session starts reading rw immediately until read fails
+func NewSessionConfig(rw io.ReadWriter, cfg SessionConfig) *Session {
+	s := &Session{
+		rw:   rw,
+		cfg:  cfg,
+		enc:  NewEncoderVer(rw, cfg.Ver),
+		dec:  NewDecoder(rw),
+		done: make(chan struct{}),
+	}
+	go s.readLoop()
+	return s
+}
+
+func (s *Session) readLoop() {
+	for {
+		p, err := s.dec.Decode()
+		if err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			close(s.done)
+			return
+		}
+		s.dispatch(p)
+	}
+}
+
+// Hand packet to the oldest request it matches, otherwise to the oldest matching subscription
+func (s *Session) dispatch(p BasePkt) {
+	s.mu.Lock()
+	for i, w := range s.waiters {
+		if w.match(p) {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			s.mu.Unlock()
+			w.ch <- p
+			return
+		}
+	}
+	for _, sub := range s.subs {
+		if sub.match(p) {
+			// Never block reader on a slow subscriber so responses keep reaching requests
+			select {
+			case sub.ch <- p:
+			default:
+				s.subDrop++
+			}
+			s.mu.Unlock()
+			return
+		}
+	}
+	s.mu.Unlock()
+	if s.cfg.Unsolicited != nil {
+		s.cfg.Unsolicited(p)
+	}
+}
+
+func (s *Session) removeWaiter(w *sessionWaiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.waiters {
+		if s.waiters[i] == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Receive packets accepted by match that are not responses to a request
+// Subscription ends when the returned cancel function is called
+// Packets arriving while 16 packets are waiting in the channel are dropped and counted by SubDropped
+func (s *Session) Subscribe(match Matcher) (<-chan BasePkt, func()) {
+	sub := &sessionSub{match: match, ch: make(chan BasePkt, 16)}
+	s.mu.Lock()
+	s.subs = append(s.subs, sub)
+	s.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			for i := range s.subs {
+				if s.subs[i] == sub {
+					s.subs = append(s.subs[:i], s.subs[i+1:]...)
+					break
+				}
+			}
+			s.mu.Unlock()
+		})
+	}
+	return sub.ch, cancel
+}
+
+// Total count of packets dropped because their subscription channel was full
+func (s *Session) SubDropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subDrop
+}
+
+// Session configuration
+func (s *Session) Config() SessionConfig {
+	return s.cfg
+}
+
+// Closed when session reader stops
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Error that stopped session reader
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close underlying link if it can be closed
+func (s *Session) Close() error {
+	if c, ok := s.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Send built packet without waiting for response
+func (s *Session) Send(buf []byte) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	return s.enc.Encode(buf)
+}
+
+// Send built packet then wait for the first packet accepted by match
+// Request is resent on response timeout up to configured retry count
+func (s *Session) Request(ctx context.Context, req []byte, match Matcher) (BasePkt, error) {
+	w := &sessionWaiter{match: match, ch: make(chan BasePkt, 1)}
+	s.mu.Lock()
+	s.waiters = append(s.waiters, w)
+	s.mu.Unlock()
+	defer s.removeWaiter(w)
+
+	for attempt := 0; ; attempt++ {
+		if err := s.Send(req); err != nil {
+			return BasePkt{}, err
+		}
+		p, err := s.await(ctx, w)
+		if err != ErrTimeout {
+			return p, err
+		}
+		if attempt >= s.cfg.Retries {
+			return BasePkt{}, fmt.Errorf("%w after %d attempts", ErrTimeout, attempt+1)
+		}
+	}
+}
+
+// Wait for response of a single request attempt
+func (s *Session) await(ctx context.Context, w *sessionWaiter) (BasePkt, error) {
+	var timeout <-chan time.Time
+	if s.cfg.Timeout > 0 {
+		timer := time.NewTimer(s.cfg.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case p := <-w.ch:
+		return p, nil
+	case <-ctx.Done():
+		return BasePkt{}, ctx.Err()
+	case <-timeout:
+		return BasePkt{}, ErrTimeout
+	case <-s.done:
+		select {
+		case p := <-w.ch:
+			return p, nil
+		default:
+			return BasePkt{}, fmt.Errorf("%w: %w", ErrClosed, s.Err())
+		}
+	}
+}
+
+// Send handshake then return handshake message of the peer
+func (s *Session) Handshake(ctx context.Context, msg []byte) ([]byte, error) {
+	p, err := s.Request(ctx, MkHandshake(msg), MatchCmd(CmdHandshake))
+	if err != nil {
+		return nil, err
+	}
+	return p.GetHandshake()
+}
+
+// Request uplink info of the peer
+func (s *Session) Uinfo(ctx context.Context, rb DeviceInfoRB) (string, error) {
+	match := func(p BasePkt) bool {
+		return MatchSub(CmdUplinkInfo, rb)(p) && p.DataLen > 1
+	}
+	p, err := s.Request(ctx, MkUinfoReq(rb), match)
+	if err != nil {
+		return "", err
+	}
+	uinfo, err := p.GetUinfo()
+	if err != nil {
+		return "", err
+	}
+	return uinfo.Resp, nil
+}
+
+// Request network reset then wait for its acknowledgement
+func (s *Session) NetReset(ctx context.Context, rb NetRstRB) error {
+	_, err := s.Request(ctx, MkNetResetReq(rb), MatchLen(CmdNetworkReset, 0))
+	return err
+}
+
+// Request time synchronization
+// Returned Tsync has NotReady set when peer time is not available yet
+func (s *Session) Tsync(ctx context.Context, rb TimesyncRB) (Tsync, error) {
+	match := func(p BasePkt) bool {
+		return MatchLen(CmdTimeSync, 0)(p) ||
+			(MatchSub(CmdTimeSync, rb)(p) && p.DataLen == uint16(LenTsync))
+	}
+	p, err := s.Request(ctx, MkTsyncReq(rb), match)
+	if err != nil {
+		return Tsync{}, err
+	}
+	return p.GetTsync()
+}
+
+// Request fault report of all DE then return the first report
+func (s *Session) DEFaultAll(ctx context.Context) (DeFault, error) {
+	match := func(p BasePkt) bool {
+		return p.CommandID == CmdDEFault &&
+			(p.DataLen == LenDefDataNoneAll || p.DataLen == LenDefDataReport)
+	}
+	p, err := s.Request(ctx, MkDeFaultAllReq(), match)
+	if err != nil {
+		return DeFault{}, err
+	}
+	return p.GetDEFault()
+}
+
+// Initiate software update then return simple reply of the peer
+func (s *Session) SwupInitiate(ctx context.Context) (SwupSrep, error) {
+	p, err := s.Request(ctx, MkSwupInitiate(), MatchLen(CmdSwUpdate, LenSwupDataSrep))
+	if err != nil {
+		return 0, err
+	}
+	swup, err := p.GetSwup()
+	if err != nil {
+		return 0, err
+	}
+	return swup.Srep, nil
+}
@@ -0,0 +1,123 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// Answer requests on conn, ignoring the first request of each command
+func sessionPeer(conn net.Conn) {
+	seen := map[CmdID]bool{}
+	d := NewDecoder(conn)
+	for {
+		p, err := d.Decode()
+		if err != nil {
+			return
+		}
+		if !seen[p.CommandID] {
+			seen[p.CommandID] = true
+			continue
+		}
+		switch p.CommandID {
+		case CmdHandshake:
+			conn.Write(MkHandshake([]byte("device")))
+		case CmdUplinkInfo:
+			conn.Write(MkNetStatusReport(NetstatOk))
+			conn.Write(MkUinfoResp(p.Data[0], "genericdevice"))
+		case CmdNetworkReset:
+			conn.Write(MkNetResetACK())
+		case CmdTimeSync:
+			conn.Write(MkTsyncNotReady())
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	SetVer(0)
+	host, dev := net.Pipe()
+	defer host.Close()
+	go sessionPeer(dev)
+
+	unsolicited := make(chan BasePkt, 4)
+	cfg := DefaultSessionConfig()
+	cfg.Timeout = 50 * time.Millisecond
+	cfg.Retries = 1
+	cfg.Unsolicited = func(p BasePkt) { unsolicited <- p }
+	s := NewSessionConfig(host, cfg)
+	ctx := context.Background()
+
+	msg, err := s.Handshake(ctx, []byte("host"))
+	if err != nil || string(msg) != "device" {
+		t.Error(err, msg)
+	}
+	name, err := s.Uinfo(ctx, DeviceName)
+	if err != nil || name != "genericdevice" {
+		t.Error(err, name)
+	}
+	p := <-unsolicited
+	if p.CommandID != CmdNetworkStatus {
+		t.Errorf("unexpected unsolicited packet %s", p)
+	}
+	if err = s.NetReset(ctx, NetAP); err != nil {
+		t.Error(err)
+	}
+	ts, err := s.Tsync(ctx, TsyncUTC)
+	if err != nil || !ts.NotReady {
+		t.Error(err, ts)
+	}
+
+	_, err = s.SwupInitiate(ctx)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout but got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	s.cfg.Timeout = 0
+	_, err = s.DEFaultAll(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded but got %v", err)
+	}
+
+	dev.Close()
+	<-s.Done()
+	_, err = s.Request(context.Background(), MkHandshake(nil), MatchCmd(CmdHandshake))
+	if err == nil {
+		t.Error("expected error on closed session")
+	}
+}
+
+func TestSessionSubscribe(t *testing.T) {
+	SetVer(0)
+	host, dev := net.Pipe()
+	defer host.Close()
+
+	s := NewSession(host)
+	reports, cancel := s.Subscribe(MatchCmd(CmdDEReport))
+	defer cancel()
+	go func() {
+		for i := 0; i < 20; i++ {
+			dev.Write(MkDeRepUint(DegSensor, 1, uint32(i)))
+		}
+		d := NewDecoder(dev)
+		if _, err := d.Decode(); err == nil {
+			dev.Write(MkHandshake([]byte("device")))
+		}
+	}()
+
+	// Subscriber not reading does not hold back responses
+	msg, err := s.Handshake(context.Background(), []byte("host"))
+	if err != nil || string(msg) != "device" {
+		t.Fatal(err, msg)
+	}
+	if len(reports) != 16 || s.SubDropped() != 4 {
+		t.Errorf("%d queued and %d dropped", len(reports), s.SubDropped())
+	}
+	p := <-reports
+	if dep, _ := p.GetDEP(); dep.Data != 0 {
+		t.Error(dep)
+	}
+}
@@ -0,0 +1,186 @@
+package pg
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DE shadow entry holding desired and reported value of one DE
+type ShadowEntry struct {
+	Group       DEGroup
+	Id          byte
+	Reported    DePkt
+	ReportedAt  time.Time
+	HasReported bool
+	Desired     DePkt
+	DesiredAt   time.Time
+	HasDesired  bool
+}
+
+// Whether desired value is set and differs from reported value
+func (e ShadowEntry) Pending() bool {
+	return e.HasDesired && (!e.HasReported || !DEPValueEqual(e.Desired, e.Reported))
+}
+
+// DE shadow change event
+type ShadowChange struct {
+	Group   DEGroup
+	Id      byte
+	Desired bool // Desired value changed, otherwise reported value changed
+	HadOld  bool
+	Old     DePkt
+	New     DePkt
+	At      time.Time
+}
+
+// Whether two DE packets carry the same type and value
+func DEPValueEqual(a, b DePkt) bool {
+	if a.Dtype != b.Dtype {
+		return false
+	}
+	if DEtypeUsesRaw(a.Dtype) {
+		return bytes.Equal(a.DataRaw, b.DataRaw)
+	}
+	return a.Data == b.Data
+}
+
+// Device state shadow tracking desired and reported value of every DE
+type Shadow struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	entries  map[deKey]*ShadowEntry
+	watchers []func(ShadowChange)
+}
+
+// Create empty device state shadow
+func NewShadow() *Shadow {
+	return &Shadow{now: time.Now, entries: map[deKey]*ShadowEntry{}}
+}
+
+// Call f on every change of desired or reported value
+func (s *Shadow) Watch(f func(ShadowChange)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers = append(s.watchers, f)
+}
+
+func (s *Shadow) entry(g DEGroup, id byte) *ShadowEntry {
+	k := deKey{g, id}
+	e, ok := s.entries[k]
+	if !ok {
+		e = &ShadowEntry{Group: g, Id: id}
+		s.entries[k] = e
+	}
+	return e
+}
+
+func (s *Shadow) update(dep DePkt, desired bool) bool {
+	s.mu.Lock()
+	e := s.entry(dep.Group, dep.Id)
+	val, at, has := &e.Reported, &e.ReportedAt, &e.HasReported
+	if desired {
+		val, at, has = &e.Desired, &e.DesiredAt, &e.HasDesired
+	}
+	ch := ShadowChange{Group: dep.Group, Id: dep.Id, Desired: desired, HadOld: *has, Old: *val, At: s.now()}
+	*at = ch.At
+	if *has && DEPValueEqual(*val, dep) {
+		s.mu.Unlock()
+		return false
+	}
+	*val = cloneDEP(dep)
+	*has = true
+	ch.New = *val
+	watchers := s.watchers
+	s.mu.Unlock()
+
+	for _, f := range watchers {
+		f(ch)
+	}
+	return true
+}
+
+// Record reported value of DE, returns whether value changed
+func (s *Shadow) Report(dep DePkt) bool {
+	return s.update(dep, false)
+}
+
+// Record desired value of DE, returns whether value changed
+func (s *Shadow) SetDesired(dep DePkt) bool {
+	return s.update(dep, true)
+}
+
+// Forget desired value of DE g/id
+func (s *Shadow) ClearDesired(g DEGroup, id byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[deKey{g, id}]; ok {
+		e.Desired = DePkt{}
+		e.HasDesired = false
+	}
+}
+
+// Record reported value of every DE in DE report packet
+func (s *Shadow) Ingest(p BasePkt) error {
+	if p.CommandID != CmdDEReport {
+		return ErrCmdId
+	}
+	depList, err := p.GetDEPList()
+	if err != nil {
+		return err
+	}
+	for _, dep := range depList {
+		s.Report(dep)
+	}
+	return nil
+}
+
+// Get shadow entry of DE g/id
+func (s *Shadow) Get(g DEGroup, id byte) (ShadowEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[deKey{g, id}]
+	if !ok {
+		return ShadowEntry{}, false
+	}
+	return *e, true
+}
+
+// All shadow entries ordered by group then id
+func (s *Shadow) Entries() []ShadowEntry {
+	s.mu.Lock()
+	entries := make([]ShadowEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, *e)
+	}
+	s.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Group != entries[j].Group {
+			return entries[i].Group < entries[j].Group
+		}
+		return entries[i].Id < entries[j].Id
+	})
+	return entries
+}
+
+// Desired values that differ from reported values, ordered by group then id
+func (s *Shadow) Delta() []DePkt {
+	delta := []DePkt{}
+	for _, e := range s.Entries() {
+		if e.Pending() {
+			delta = append(delta, e.Desired)
+		}
+	}
+	return delta
+}
+
+// Make DE set packet bringing reported values to desired values
+// Returns nil when every desired value is already reported
+func (s *Shadow) Reconcile() []byte {
+	delta := s.Delta()
+	if len(delta) == 0 {
+		return nil
+	}
+	return MkDESList(delta)
+}
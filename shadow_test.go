@@ -0,0 +1,62 @@
+package pg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShadow(t *testing.T) {
+	SetVer(0)
+	s := NewShadow()
+	tm := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return tm }
+	changes := []ShadowChange{}
+	s.Watch(func(ch ShadowChange) { changes = append(changes, ch) })
+
+	p, _ := Parse(MkDERList([]DePkt{
+		{Group: DegControl, Id: 7, Dtype: DEtypeUint, Data: 1000},
+		{Group: DegSensor, Id: 0, Dtype: DEtypeString, Dlen: 4, DataRaw: []byte("warm")},
+	}))
+	if err := s.Ingest(p); err != nil {
+		t.Fatal(err)
+	}
+	tm = tm.Add(time.Minute)
+	p, _ = Parse(MkDeRepUint(DegControl, 7, 1000))
+	s.Ingest(p)
+	if len(changes) != 2 {
+		t.Errorf("expected 2 changes but got %d", len(changes))
+	}
+	e, ok := s.Get(DegControl, 7)
+	if !ok || !e.ReportedAt.Equal(tm) || e.Reported.Data != 1000 {
+		t.Error(e)
+	}
+
+	if s.Reconcile() != nil {
+		t.Error("nothing to reconcile yet")
+	}
+	s.SetDesired(DePkt{Group: DegControl, Id: 7, Dtype: DEtypeUint, Data: 2000})
+	s.SetDesired(DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1})
+	s.SetDesired(DePkt{Group: DegSensor, Id: 0, Dtype: DEtypeString, Dlen: 4, DataRaw: []byte("warm")})
+	if len(changes) != 5 || !changes[2].Desired || changes[2].Old.Data != 0 || changes[2].New.Data != 2000 {
+		t.Error(changes)
+	}
+
+	buf := s.Reconcile()
+	p, err := Parse(buf)
+	if err != nil || p.CommandID != CmdDESet {
+		t.Fatal(err, p)
+	}
+	depList, err := p.GetDEPList()
+	if err != nil || len(depList) != 2 || depList[0].Id != 1 || depList[1].Data != 2000 {
+		t.Error(err, depList)
+	}
+
+	p, _ = Parse(MkDERList(depList))
+	s.Ingest(p)
+	if buf = s.Reconcile(); buf != nil {
+		t.Errorf("unexpected reconcile %x", buf)
+	}
+	if p, _ = Parse(MkDeSetBool(DegControl, 1, true)); s.Ingest(p) != ErrCmdId {
+		t.Error("DE set packet ingested")
+	}
+}
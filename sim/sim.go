@@ -0,0 +1,303 @@
+// Simulates a pg device for testing host code without hardware
+package sim
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ucukertz/pg"
+)
+
+// Simulated device configuration
+type Config struct {
+	Ver       byte                       // pg version
+	Handshake []byte                     // Handshake message sent as reply
+	Info      map[pg.DeviceInfoRB]string // Uplink info strings, requests for missing ones get no response
+	Netstat   pg.NetstatData             // Initial network status
+	DE        []pg.DePkt                 // Initial DE values
+	Faults    []pg.DeFault               // DE faults reported on request
+	ChunkSize uint16                     // Largest accepted software update chunk size
+}
+
+type deKey struct {
+	group pg.DEGroup
+	id    byte
+}
+
+// Simulated pg device
+type Device struct {
+	cfg Config
+
+	mu      sync.Mutex
+	w       *pg.ResponseWriter
+	netstat pg.NetstatData
+	deKeys  []deKey
+	de      map[deKey]pg.DePkt
+	sch     []pg.SchPkt
+	tm      time.Time
+	swupBuf memImage
+	image   []byte
+}
+
+// Growable in-memory io.WriterAt
+type memImage struct {
+	buf []byte
+}
+
+func (m *memImage) WriteAt(p []byte, off int64) (int, error) {
+	end := int(off) + len(p)
+	if end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	return copy(m.buf[off:], p), nil
+}
+
+// Create simulated device from cfg
+func New(cfg Config) *Device {
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = 256
+	}
+	d := &Device{cfg: cfg, netstat: cfg.Netstat}
+	d.resetDE()
+	return d
+}
+
+func (d *Device) resetDE() {
+	d.deKeys = d.deKeys[:0]
+	d.de = map[deKey]pg.DePkt{}
+	for _, dep := range d.cfg.DE {
+		k := deKey{dep.Group, dep.Id}
+		d.deKeys = append(d.deKeys, k)
+		d.de[k] = dep
+	}
+}
+
+// Answer pg packets over rw until reading rw fails
+// Returns nil when rw reaches io.EOF
+func (d *Device) Serve(rw io.ReadWriter) error {
+	enc := pg.NewEncoderVer(rw, d.cfg.Ver)
+	dec := pg.NewDecoderConfig(rw, pg.DecoderConfig{Ver: d.cfg.Ver, CheckVer: true})
+	w := pg.NewResponseWriter(enc)
+	d.mu.Lock()
+	d.w = w
+	d.mu.Unlock()
+	return d.mux().Serve(dec, w)
+}
+
+func (d *Device) mux() *pg.Mux {
+	m := pg.NewMux()
+	m.HandleFunc(pg.CmdHandshake, d.handshake)
+	m.HandleFunc(pg.CmdUplinkInfo, d.uinfo)
+	m.HandleFunc(pg.CmdNetworkReset, d.netReset)
+	m.HandleFunc(pg.CmdTimeSync, d.tsync)
+	m.HandleFunc(pg.CmdDESet, d.deSet)
+	m.HandleFunc(pg.CmdDEFault, d.deFault)
+	m.HandleFunc(pg.CmdSchedule, d.schedule)
+	m.Handle(pg.CmdSwUpdate, d.swupReceiver())
+	return m
+}
+
+// Send built packet to host
+func (d *Device) Send(buf []byte) error {
+	d.mu.Lock()
+	w := d.w
+	d.mu.Unlock()
+	if w == nil {
+		return io.ErrClosedPipe
+	}
+	return w.Send(buf)
+}
+
+// Change network status then report it to host
+func (d *Device) SetNetstat(n pg.NetstatData) error {
+	d.mu.Lock()
+	d.netstat = n
+	d.mu.Unlock()
+	return d.Send(pg.MkNetStatusReport(n))
+}
+
+// Current network status
+func (d *Device) Netstat() pg.NetstatData {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.netstat
+}
+
+// Current value of DE g/id
+func (d *Device) DE(g pg.DEGroup, id byte) (pg.DePkt, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dep, ok := d.de[deKey{g, id}]
+	return dep, ok
+}
+
+// Change value of DE then report it to host
+func (d *Device) ReportDE(dep pg.DePkt) error {
+	d.mu.Lock()
+	k := deKey{dep.Group, dep.Id}
+	if _, ok := d.de[k]; !ok {
+		d.deKeys = append(d.deKeys, k)
+	}
+	d.de[k] = dep
+	d.mu.Unlock()
+	return d.Send(pg.MkDERList([]pg.DePkt{dep}))
+}
+
+// Stored schedules
+func (d *Device) Schedules() []pg.SchPkt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]pg.SchPkt{}, d.sch...)
+}
+
+// Time received from the last time synchronization response
+func (d *Device) Time() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.tm
+}
+
+// Image of the last successful software update
+func (d *Device) Image() []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.image
+}
+
+func (d *Device) handshake(w *pg.ResponseWriter, p pg.BasePkt) {
+	w.Handshake(d.cfg.Handshake)
+}
+
+func (d *Device) uinfo(w *pg.ResponseWriter, p pg.BasePkt) {
+	uinfo, err := p.GetUinfo()
+	if err != nil || uinfo.IsResp {
+		return
+	}
+	if !uinfo.All {
+		if info, ok := d.cfg.Info[uinfo.Rb]; ok {
+			w.UinfoResp(uinfo.Rb, info)
+		}
+		return
+	}
+	for rb := pg.UplinkDest; rb <= pg.DeviceID; rb++ {
+		if info, ok := d.cfg.Info[rb]; ok {
+			w.UinfoResp(rb, info)
+		}
+	}
+}
+
+func (d *Device) netReset(w *pg.ResponseWriter, p pg.BasePkt) {
+	nr, err := p.GetNetReset()
+	if err != nil || nr.Ack {
+		return
+	}
+	w.NetResetACK()
+	n := pg.NetstatCfgAP
+	switch nr.Rb {
+	case pg.NetSC:
+		n = pg.NetstatCfgSC
+	case pg.NetQC:
+		n = pg.NetstatCfgQC
+	}
+	d.mu.Lock()
+	d.netstat = n
+	d.mu.Unlock()
+	w.NetStatusReport(n)
+}
+
+func (d *Device) tsync(w *pg.ResponseWriter, p pg.BasePkt) {
+	ts, err := p.GetTsync()
+	if err != nil || !ts.IsResp {
+		return
+	}
+	d.mu.Lock()
+	d.tm = ts.Time
+	d.mu.Unlock()
+}
+
+func (d *Device) deSet(w *pg.ResponseWriter, p pg.BasePkt) {
+	depList, err := p.GetDEPList()
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	if len(depList) == 0 {
+		d.resetDE()
+		for _, k := range d.deKeys {
+			depList = append(depList, d.de[k])
+		}
+		d.mu.Unlock()
+		w.DERep(depList...)
+		return
+	}
+	applied := make([]pg.DePkt, 0, len(depList))
+	faults := []pg.DeFault{}
+	for _, dep := range depList {
+		k := deKey{dep.Group, dep.Id}
+		cur, ok := d.de[k]
+		if !ok {
+			faults = append(faults, pg.DeFault{Group: dep.Group, Id: dep.Id, Fault: pg.DefNotAvailable})
+			continue
+		}
+		if cur.Dtype != dep.Dtype {
+			faults = append(faults, pg.DeFault{Group: dep.Group, Id: dep.Id, Fault: pg.DefMalformed})
+			continue
+		}
+		d.de[k] = dep
+		applied = append(applied, dep)
+	}
+	d.mu.Unlock()
+
+	for _, f := range faults {
+		w.DeFaultRep(f.Group, f.Id, f.Fault)
+	}
+	if len(applied) > 0 {
+		w.DERep(applied...)
+	}
+}
+
+func (d *Device) deFault(w *pg.ResponseWriter, p pg.BasePkt) {
+	def, err := p.GetDEFault()
+	if err != nil || def.Kind != pg.DefKindReqAll {
+		return
+	}
+	if len(d.cfg.Faults) == 0 {
+		w.Send(pg.MkDeFaultNoneAll())
+		return
+	}
+	for _, f := range d.cfg.Faults {
+		w.DeFaultRep(f.Group, f.Id, f.Fault)
+	}
+}
+
+func (d *Device) schedule(w *pg.ResponseWriter, p pg.BasePkt) {
+	sch, err := p.GetSch()
+	if err != nil || sch.Kind == pg.SchKindExecReport {
+		return
+	}
+	d.mu.Lock()
+	d.sch = sch.List
+	d.mu.Unlock()
+}
+
+func (d *Device) swupReceiver() *pg.SwupReceiver {
+	cfg := pg.DefaultSwupReceiverConfig()
+	cfg.MaxChunkSize = d.cfg.ChunkSize
+	cfg.Accept = func() pg.SwupSrep {
+		d.mu.Lock()
+		d.swupBuf = memImage{}
+		d.mu.Unlock()
+		return pg.SrepAccept
+	}
+	cfg.Done = func(size int64, err error) {
+		if err != nil {
+			return
+		}
+		d.mu.Lock()
+		d.image = d.swupBuf.buf[:size]
+		d.mu.Unlock()
+	}
+	return pg.NewSwupReceiver(&d.swupBuf, cfg)
+}
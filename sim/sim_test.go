@@ -0,0 +1,123 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ucukertz/pg"
+)
+
+func TestDevice(t *testing.T) {
+	pg.SetVer(0)
+	host, dev := net.Pipe()
+	defer host.Close()
+	d := New(Config{
+		Handshake: []byte("simdevice"),
+		Info:      map[pg.DeviceInfoRB]string{pg.DeviceName: "sim", pg.DeviceType: "fan"},
+		Netstat:   pg.NetstatOk,
+		DE: []pg.DePkt{
+			{Group: pg.DegControl, Id: 1, Dtype: pg.DEtypeBool},
+			{Group: pg.DegControl, Id: 7, Dtype: pg.DEtypeUint, Data: 3},
+		},
+	})
+	go d.Serve(dev)
+
+	unsolicited := make(chan pg.BasePkt, 16)
+	cfg := pg.DefaultSessionConfig()
+	cfg.Unsolicited = func(p pg.BasePkt) { unsolicited <- p }
+	s := pg.NewSessionConfig(host, cfg)
+	ctx := context.Background()
+
+	msg, err := s.Handshake(ctx, []byte("host"))
+	if err != nil || string(msg) != "simdevice" {
+		t.Error(err, msg)
+	}
+	name, err := s.Uinfo(ctx, pg.DeviceName)
+	if err != nil || name != "sim" {
+		t.Error(err, name)
+	}
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	_, err = s.Uinfo(tctx, pg.DeviceID)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected no reply for unconfigured info but got %v", err)
+	}
+	if err = s.NetReset(ctx, pg.NetSC); err != nil {
+		t.Error(err)
+	}
+	p := <-unsolicited
+	ns, err := p.GetNetStatus()
+	if err != nil || ns.Data != pg.NetstatCfgSC {
+		t.Error(err, ns)
+	}
+
+	p, err = s.Request(ctx, pg.MkDeSetUint(pg.DegControl, 7, 42), pg.MatchCmd(pg.CmdDEReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dep, err := p.GetDEP()
+	if err != nil || dep.Data != 42 {
+		t.Error(err, dep)
+	}
+	if dep, _ = d.DE(pg.DegControl, 7); dep.Data != 42 {
+		t.Error(dep)
+	}
+	p, err = s.Request(ctx, pg.MkDeSetUint(pg.DegControl, 9, 1), pg.MatchCmd(pg.CmdDEFault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, err := p.GetDEFault()
+	if err != nil || def.Id != 9 || def.Fault != pg.DefNotAvailable {
+		t.Error(err, def)
+	}
+	def, err = s.DEFaultAll(ctx)
+	if err != nil || def.Kind != pg.DefKindNoneAll {
+		t.Error(err, def)
+	}
+
+	sch := []pg.SchPkt{{Id: 1, Weekdays: 0b0111110, Hour: 7, Minute: 30,
+		Dep: pg.DePkt{Group: pg.DegControl, Id: 1, Dtype: pg.DEtypeBool, Data: 1}}}
+	if err = s.Send(pg.MkSchSet(sch)); err != nil {
+		t.Fatal(err)
+	}
+	tm := time.Date(2026, time.October, 17, 7, 30, 0, 0, time.UTC)
+	if err = s.Send(pg.MkTsyncResp(pg.TsyncUTC, tm)); err != nil {
+		t.Fatal(err)
+	}
+	// Handshake round trip orders the previous packets before inspecting device state
+	if _, err = s.Handshake(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Schedules(); len(got) != 1 || got[0].Hour != 7 {
+		t.Error(got)
+	}
+	if !d.Time().Equal(tm) {
+		t.Error(d.Time())
+	}
+
+	srep, err := s.SwupInitiate(ctx)
+	if err != nil || srep != pg.SrepAccept {
+		t.Error(err, srep)
+	}
+}
+
+func TestDeviceSwup(t *testing.T) {
+	pg.SetVer(0)
+	host, dev := net.Pipe()
+	defer host.Close()
+	d := New(Config{ChunkSize: 32})
+	go d.Serve(dev)
+
+	fw := bytes.Repeat([]byte{0xDE, 0xAD, 0xBE, 0xEF}, 64)
+	u := pg.NewSwupSender(pg.NewSession(host), bytes.NewReader(fw), int64(len(fw)), pg.DefaultSwupSenderConfig())
+	if err := u.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(d.Image(), fw) {
+		t.Errorf("device image has %d bytes", len(d.Image()))
+	}
+}
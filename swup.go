@@ -0,0 +1,391 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Map software update error to Go error, SwupOk maps to nil
+func SwupErrToError(e SwupErr) error {
+	switch e {
+	case SwupOk:
+		return nil
+	case SwupErrUnknown:
+		return ErrSwupUnknown
+	case SwupErrConn:
+		return ErrSwupConn
+	case SwupErrOom:
+		return ErrSwupOom
+	default:
+		return fmt.Errorf("%w: error code 0x%x", ErrSwupUnknown, e)
+	}
+}
+
+// Map software update simple reply to Go error, SrepAccept maps to nil
+func SwupSrepToError(srep SwupSrep) error {
+	switch srep {
+	case SrepAccept:
+		return nil
+	case SrepReject:
+		return ErrSwupReject
+	case SrepNoInfo:
+		return ErrSwupNoInfo
+	case SrepBusy:
+		return ErrSwupBusy
+	default:
+		return fmt.Errorf("%w: simple reply 0x%x", ErrInvalidData, srep)
+	}
+}
+
+// Software update sender configuration
+type SwupSenderConfig struct {
+	ChunkSize   uint16                  // Proposed chunk size, device may lower it
+	BusyRetries int                     // Initiate retry count while device is busy
+	BusyDelay   time.Duration           // Delay between initiate retries
+	Timeout     time.Duration           // Longest wait for device chunk request or status
+	Progress    func(sent, total int64) // Called after every chunk sent
+}
+
+// Default software update sender configuration
+func DefaultSwupSenderConfig() SwupSenderConfig {
+	return SwupSenderConfig{ChunkSize: 256, BusyRetries: 3, BusyDelay: time.Second, Timeout: 5 * time.Second}
+}
+
+// Host side software update sender serving a firmware image
+type SwupSender struct {
+	s    *Session
+	img  io.ReaderAt
+	size int64
+	cfg  SwupSenderConfig
+}
+
+// Create software update sender serving size bytes of img over s
+func NewSwupSender(s *Session, img io.ReaderAt, size int64, cfg SwupSenderConfig) *SwupSender {
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultSwupSenderConfig().ChunkSize
+	}
+	return &SwupSender{s: s, img: img, size: size, cfg: cfg}
+}
+
+// Run software update until device reports its final status
+func (u *SwupSender) Run(ctx context.Context) error {
+	// Subscribe before initiating so no early chunk request is missed
+	match := func(p BasePkt) bool {
+		return p.CommandID == CmdSwUpdate &&
+			(p.DataLen == LenSwupDataChunkReq || p.DataLen == LenSwupDataStatus)
+	}
+	pkts, cancel := u.s.Subscribe(match)
+	defer cancel()
+
+	if err := u.initiate(ctx); err != nil {
+		return err
+	}
+	chunksz, err := u.negotiate(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		p, err := u.next(ctx, pkts)
+		if err != nil {
+			u.s.Send(MkSwupStatus(true, false, SwupErrConn))
+			return err
+		}
+		swup, err := p.GetSwup()
+		if err != nil {
+			return err
+		}
+
+		if swup.Scmd == SwupScmdStatus {
+			if !swup.Status.Finish {
+				continue
+			}
+			if swup.Status.Success {
+				return nil
+			}
+			if err = SwupErrToError(swup.Status.Err); err != nil {
+				return err
+			}
+			return ErrSwupFailed
+		}
+
+		if err = u.serveChunk(swup.Chunk.Idx, chunksz); err != nil {
+			u.s.Send(MkSwupStatus(true, false, SwupErrUnknown))
+			return err
+		}
+	}
+}
+
+// Initiate software update, retrying while device is busy
+func (u *SwupSender) initiate(ctx context.Context) error {
+	for attempt := 0; ; attempt++ {
+		srep, err := u.s.SwupInitiate(ctx)
+		if err != nil {
+			return err
+		}
+		err = SwupSrepToError(srep)
+		if err != ErrSwupBusy || attempt >= u.cfg.BusyRetries {
+			return err
+		}
+		select {
+		case <-time.After(u.cfg.BusyDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Propose chunk size then return chunk size accepted by device
+func (u *SwupSender) negotiate(ctx context.Context) (uint16, error) {
+	p, err := u.s.Request(ctx, MkSwupSetChunksz(u.cfg.ChunkSize), MatchLen(CmdSwUpdate, LenSwupDataChunksz))
+	if err != nil {
+		return 0, err
+	}
+	swup, err := p.GetSwup()
+	if err != nil {
+		return 0, err
+	}
+	if swup.Chunk.Size == 0 || swup.Chunk.Size > u.cfg.ChunkSize {
+		return 0, fmt.Errorf("%w: chunk size %d", ErrInvalidData, swup.Chunk.Size)
+	}
+	return swup.Chunk.Size, nil
+}
+
+// Wait for next chunk request or status from device
+func (u *SwupSender) next(ctx context.Context, pkts <-chan BasePkt) (BasePkt, error) {
+	var timeout <-chan time.Time
+	if u.cfg.Timeout > 0 {
+		timer := time.NewTimer(u.cfg.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case p := <-pkts:
+		return p, nil
+	case <-ctx.Done():
+		return BasePkt{}, ctx.Err()
+	case <-timeout:
+		return BasePkt{}, fmt.Errorf("%w waiting for chunk request", ErrTimeout)
+	case <-u.s.Done():
+		return BasePkt{}, fmt.Errorf("%w: %w", ErrClosed, u.s.Err())
+	}
+}
+
+// Send requested chunk, or finished status when idx is past the end of image
+func (u *SwupSender) serveChunk(idx uint32, chunksz uint16) error {
+	off := int64(idx) * int64(chunksz)
+	if off >= u.size {
+		return u.s.Send(MkSwupStatus(true, true, SwupOk))
+	}
+	n := int64(chunksz)
+	if u.size-off < n {
+		n = u.size - off
+	}
+	chunk := make([]byte, n)
+	if _, err := u.img.ReadAt(chunk, off); err != nil && err != io.EOF {
+		return err
+	}
+	if err := u.s.Send(MkSwupChunk(idx, chunk)); err != nil {
+		return err
+	}
+	if u.cfg.Progress != nil {
+		u.cfg.Progress(off+n, u.size)
+	}
+	return nil
+}
+
+// Software update receiver configuration
+type SwupReceiverConfig struct {
+	MaxChunkSize uint16                      // Largest accepted chunk size
+	Timeout      time.Duration               // Wait for each chunk before requesting it again
+	Retries      int                         // Re-request count of the same chunk after its first request
+	Accept       func() SwupSrep             // Decides reply to initiate, nil always accepts
+	Verify       func(size int64) SwupErr    // Checks received image before reporting final status
+	Progress     func(received int64)        // Called after every chunk written
+	Done         func(size int64, err error) // Called once update ends, before final status is sent
+}
+
+// Default software update receiver configuration
+func DefaultSwupReceiverConfig() SwupReceiverConfig {
+	return SwupReceiverConfig{MaxChunkSize: 256, Timeout: time.Second, Retries: 3}
+}
+
+// Device side software update receiver writing chunks to io.WriterAt
+// Implements Handler so it can be registered for CmdSwUpdate on a Mux
+type SwupReceiver struct {
+	wa  io.WriterAt
+	cfg SwupReceiverConfig
+
+	mu       sync.Mutex
+	w        *ResponseWriter
+	active   bool
+	size     uint16
+	idx      uint32
+	tries    int
+	received int64
+	timer    *time.Timer
+}
+
+// Create software update receiver writing image to wa
+func NewSwupReceiver(wa io.WriterAt, cfg SwupReceiverConfig) *SwupReceiver {
+	if cfg.MaxChunkSize == 0 {
+		cfg.MaxChunkSize = DefaultSwupReceiverConfig().MaxChunkSize
+	}
+	return &SwupReceiver{wa: wa, cfg: cfg}
+}
+
+// Handle software update packet from host
+func (r *SwupReceiver) ServePg(w *ResponseWriter, p BasePkt) {
+	swup, err := p.GetSwup()
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w = w
+	switch swup.Scmd {
+	case SwupScmdInitiate:
+		r.initiate()
+	case SwupScmdChunksz:
+		if r.active && r.size == 0 {
+			r.size = swup.Chunk.Size
+			if r.size == 0 || r.size > r.cfg.MaxChunkSize {
+				r.size = r.cfg.MaxChunkSize
+			}
+			r.w.Send(MkSwupSetChunksz(r.size))
+			r.request(0)
+		}
+	case SwupScmdChunk:
+		if r.active && r.size > 0 {
+			r.chunk(swup.Chunk)
+		}
+	case SwupScmdStatus:
+		if r.active && swup.Status.Finish {
+			if swup.Status.Success {
+				r.finish()
+				return
+			}
+			err = SwupErrToError(swup.Status.Err)
+			if err == nil {
+				err = ErrSwupFailed
+			}
+			r.stop(err)
+		}
+	}
+}
+
+// Reply to initiate, caller must hold r.mu
+func (r *SwupReceiver) initiate() {
+	if r.active && r.size > 0 {
+		r.w.SwupSrep(SrepBusy)
+		return
+	}
+	srep := SrepAccept
+	if r.cfg.Accept != nil {
+		srep = r.cfg.Accept()
+	}
+	if srep == SrepAccept {
+		r.active = true
+		r.size = 0
+		r.idx = 0
+		r.tries = 0
+		r.received = 0
+	}
+	r.w.SwupSrep(srep)
+}
+
+// Request chunk idx and arm re-request timer, caller must hold r.mu
+func (r *SwupReceiver) request(idx uint32) {
+	if idx != r.idx {
+		r.idx = idx
+		r.tries = 0
+	}
+	r.tries++
+	r.w.Send(MkSwupChunkReq(idx))
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if r.cfg.Timeout > 0 {
+		r.timer = time.AfterFunc(r.cfg.Timeout, func() { r.timeout(idx) })
+	}
+}
+
+func (r *SwupReceiver) timeout(idx uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || r.idx != idx {
+		return
+	}
+	if r.tries > r.cfg.Retries {
+		r.fail(SwupErrConn)
+		return
+	}
+	r.request(idx)
+}
+
+// Write received chunk then request the next one, caller must hold r.mu
+func (r *SwupReceiver) chunk(c SwupChunk) {
+	if c.Idx != r.idx {
+		if r.tries > r.cfg.Retries {
+			r.fail(SwupErrConn)
+			return
+		}
+		r.request(r.idx)
+		return
+	}
+	// Longer chunk would overwrite the range of the next one
+	if len(c.Data) > int(r.size) {
+		r.fail(SwupErrUnknown)
+		return
+	}
+	if _, err := r.wa.WriteAt(c.Data, int64(c.Idx)*int64(r.size)); err != nil {
+		r.fail(SwupErrUnknown)
+		return
+	}
+	r.received += int64(len(c.Data))
+	if r.cfg.Progress != nil {
+		r.cfg.Progress(r.received)
+	}
+	if c.Size < r.size {
+		r.finish()
+		return
+	}
+	r.request(r.idx + 1)
+}
+
+// Verify image then report final status, caller must hold r.mu
+func (r *SwupReceiver) finish() {
+	e := SwupOk
+	if r.cfg.Verify != nil {
+		e = r.cfg.Verify(r.received)
+	}
+	if e != SwupOk {
+		r.fail(e)
+		return
+	}
+	r.stop(nil)
+	r.w.Send(MkSwupStatus(true, true, SwupOk))
+}
+
+// Report failure status, caller must hold r.mu
+func (r *SwupReceiver) fail(e SwupErr) {
+	r.stop(SwupErrToError(e))
+	r.w.Send(MkSwupStatus(true, false, e))
+}
+
+// End update then report its result to Done callback, caller must hold r.mu
+func (r *SwupReceiver) stop(err error) {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.active = false
+	r.size = 0
+	if r.cfg.Done != nil {
+		r.cfg.Done(r.received, err)
+	}
+}
@@ -0,0 +1,253 @@
+package pg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// Minimal device side of software update replying busy to the first initiate
+func swupPeer(conn net.Conn, chunksz uint16, fail SwupErr) []byte {
+	var img []byte
+	busy := true
+	d := NewDecoder(conn)
+	for {
+		p, err := d.Decode()
+		if err != nil {
+			return img
+		}
+		swup, err := p.GetSwup()
+		if err != nil {
+			return img
+		}
+		switch swup.Scmd {
+		case SwupScmdInitiate:
+			if busy {
+				busy = false
+				conn.Write(MkSwupSrep(SrepBusy))
+			} else {
+				conn.Write(MkSwupSrep(SrepAccept))
+			}
+		case SwupScmdChunksz:
+			conn.Write(MkSwupSetChunksz(chunksz))
+			conn.Write(MkSwupChunkReq(0))
+		case SwupScmdChunk:
+			img = append(img, swup.Chunk.Data...)
+			conn.Write(MkSwupChunkReq(swup.Chunk.Idx + 1))
+		case SwupScmdStatus:
+			conn.Write(MkSwupStatus(true, fail == SwupOk, fail))
+		}
+	}
+}
+
+func TestSwupSender(t *testing.T) {
+	SetVer(0)
+	fw := bytes.Repeat([]byte("firmware"), 100)
+	for _, fail := range []SwupErr{SwupOk, SwupErrOom} {
+		host, dev := net.Pipe()
+		got := make(chan []byte)
+		go func() { got <- swupPeer(dev, 64, fail) }()
+
+		cfg := DefaultSwupSenderConfig()
+		cfg.ChunkSize = 100
+		cfg.BusyDelay = time.Millisecond
+		var sent int64
+		cfg.Progress = func(n, total int64) { sent = n }
+		u := NewSwupSender(NewSession(host), bytes.NewReader(fw), int64(len(fw)), cfg)
+		err := u.Run(context.Background())
+		host.Close()
+		img := <-got
+
+		if fail == SwupOk {
+			if err != nil {
+				t.Error(err)
+			}
+			if !bytes.Equal(img, fw) || sent != int64(len(fw)) {
+				t.Errorf("sent %d bytes, device got %d bytes", sent, len(img))
+			}
+		} else if !errors.Is(err, ErrSwupOom) {
+			t.Errorf("expected out of memory but got %v", err)
+		}
+	}
+}
+
+// Growable in-memory io.WriterAt
+type swupImage struct {
+	buf []byte
+}
+
+func (m *swupImage) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	return copy(m.buf[off:], p), nil
+}
+
+func TestSwupReceiver(t *testing.T) {
+	SetVer(0)
+	fw := bytes.Repeat([]byte("image"), 51)
+	for _, verify := range []SwupErr{SwupOk, SwupErrOom} {
+		host, dev := net.Pipe()
+		img := &swupImage{}
+		result := make(chan error, 1)
+		cfg := DefaultSwupReceiverConfig()
+		cfg.MaxChunkSize = 16
+		cfg.Verify = func(size int64) SwupErr { return verify }
+		cfg.Done = func(size int64, err error) {
+			if size != int64(len(fw)) {
+				t.Errorf("expected %d bytes but got %d", len(fw), size)
+			}
+			result <- err
+		}
+		m := NewMux()
+		m.Handle(CmdSwUpdate, NewSwupReceiver(img, cfg))
+		go m.ServeConn(dev)
+
+		u := NewSwupSender(NewSession(host), bytes.NewReader(fw), int64(len(fw)), DefaultSwupSenderConfig())
+		err := u.Run(context.Background())
+		rerr := <-result
+		host.Close()
+
+		if verify == SwupOk {
+			if err != nil || rerr != nil {
+				t.Error(err, rerr)
+			}
+			if !bytes.Equal(img.buf, fw) {
+				t.Errorf("received image differs, %d bytes", len(img.buf))
+			}
+		} else if !errors.Is(err, ErrSwupOom) || !errors.Is(rerr, ErrSwupOom) {
+			t.Error(err, rerr)
+		}
+	}
+}
+
+// Response writer whose software update replies arrive on the returned channel
+func swupReplies() (*ResponseWriter, <-chan Swup) {
+	pr, pw := io.Pipe()
+	replies := make(chan Swup, 16)
+	go func() {
+		d := NewDecoder(pr)
+		for {
+			p, err := d.Decode()
+			if err != nil {
+				close(replies)
+				return
+			}
+			if swup, err := p.GetSwup(); err == nil {
+				replies <- swup
+			}
+		}
+	}()
+	return NewResponseWriter(NewEncoder(pw)), replies
+}
+
+// Collect replies up to and including final status
+func swupUntilStatus(t *testing.T, replies <-chan Swup) []Swup {
+	t.Helper()
+	got := []Swup{}
+	for {
+		select {
+		case swup := <-replies:
+			got = append(got, swup)
+			if swup.Scmd == SwupScmdStatus {
+				return got
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no final status after %v", got)
+		}
+	}
+}
+
+func TestSwupReceiverRerequest(t *testing.T) {
+	SetVer(0)
+	w, replies := swupReplies()
+	img := &swupImage{}
+	cfg := DefaultSwupReceiverConfig()
+	cfg.Timeout = 10 * time.Millisecond
+	cfg.Retries = 1
+	result := make(chan error, 1)
+	cfg.Done = func(size int64, err error) { result <- err }
+	r := NewSwupReceiver(img, cfg)
+
+	serve := func(buf []byte) {
+		p, err := Parse(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.ServePg(w, p)
+	}
+	serve(MkSwupInitiate())
+	serve(MkSwupSetChunksz(4))
+	serve(MkSwupChunk(1, []byte{1, 2, 3, 4}))
+	if err := <-result; !errors.Is(err, ErrSwupConn) {
+		t.Errorf("expected connection error but got %v", err)
+	}
+
+	reqs := []uint32{}
+	for _, swup := range swupUntilStatus(t, replies) {
+		if swup.Scmd == SwupScmdChunkReq {
+			reqs = append(reqs, swup.Chunk.Idx)
+		}
+	}
+	if len(reqs) != 2 || reqs[0] != 0 || reqs[1] != 0 {
+		t.Errorf("unexpected chunk requests %v", reqs)
+	}
+}
+
+func TestSwupReceiverRetries(t *testing.T) {
+	SetVer(0)
+	w, replies := swupReplies()
+	cfg := DefaultSwupReceiverConfig()
+	cfg.Timeout = 10 * time.Millisecond
+	cfg.Retries = 2
+	result := make(chan error, 1)
+	cfg.Done = func(size int64, err error) { result <- err }
+	r := NewSwupReceiver(&swupImage{}, cfg)
+
+	for _, buf := range [][]byte{MkSwupInitiate(), MkSwupSetChunksz(4)} {
+		p, _ := Parse(buf)
+		r.ServePg(w, p)
+	}
+	if err := <-result; !errors.Is(err, ErrSwupConn) {
+		t.Errorf("expected connection error but got %v", err)
+	}
+	// First request and Retries re-requests
+	reqs := 0
+	for _, swup := range swupUntilStatus(t, replies) {
+		if swup.Scmd == SwupScmdChunkReq {
+			reqs++
+		}
+	}
+	if reqs != cfg.Retries+1 {
+		t.Errorf("expected %d chunk requests but got %d", cfg.Retries+1, reqs)
+	}
+}
+
+func TestSwupReceiverOversizedChunk(t *testing.T) {
+	SetVer(0)
+	w, replies := swupReplies()
+	img := &swupImage{}
+	cfg := DefaultSwupReceiverConfig()
+	result := make(chan error, 1)
+	cfg.Done = func(size int64, err error) { result <- err }
+	r := NewSwupReceiver(img, cfg)
+
+	for _, buf := range [][]byte{MkSwupInitiate(), MkSwupSetChunksz(4), MkSwupChunk(0, []byte{1, 2, 3, 4, 5})} {
+		p, err := Parse(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.ServePg(w, p)
+	}
+	if err := <-result; !errors.Is(err, ErrSwupUnknown) || len(img.buf) != 0 {
+		t.Errorf("expected unknown error without writes but got %v, %d bytes", err, len(img.buf))
+	}
+	got := swupUntilStatus(t, replies)
+	if last := got[len(got)-1]; last.Status.Success || last.Status.Err != SwupErrUnknown {
+		t.Errorf("unexpected final status %+v", last)
+	}
+}
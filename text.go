@@ -0,0 +1,734 @@
+package pg
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Canonical text format of packets, one packet per line
+//
+//	[ver=N] COMMAND [body]
+//
+// Version is written only when it differs from default pg version. Body by command:
+//
+//	HANDSHAKE "pg"
+//	UPLINK_INFO | UPLINK_INFO device_name | UPLINK_INFO device_name "Ceiling Fan"
+//	NETWORK_RESET ack | NETWORK_RESET default | ap | sc | qc
+//	NETWORK_STATUS ack | NETWORK_STATUS no_cfg | no_conn | no_uplink | ok | cfg_ap | cfg_sc | cfg_qc
+//	TIME_SYNC not_ready | TIME_SYNC utc | TIME_SYNC local 2024-03-04T07:30:00
+//	DE_SET control/3 uint=42 info/0 string="SN1234" sensor/1 fixed=-12.3,scale=0.1,offset=-40
+//	DE_FAULT | DE_FAULT none | DE_FAULT ack control/1 | DE_FAULT report sensor/1 broken
+//	SCHEDULE erase | SCHEDULE exec id=1 | SCHEDULE set [id=1 Mon,Wed 07:30 control/1 bool=true] ...
+//	SW_UPDATE initiate | srep accept | chunksz 256 | status finish=true success=true err=ok |
+//	          chunkreq idx=3 | chunk idx=3 #6368756e6b
+//
+// Bitmaps are written as 0b literals of their width. Data bytes are written in hex after '#',
+// DE whose value can not be written exactly use type=#hex and other packets use COMMAND #hex.
+
+var (
+	cmdTextNames = []string{"HANDSHAKE", "UPLINK_INFO", "NETWORK_RESET", "NETWORK_STATUS", "TIME_SYNC", "DE_SET", "DE_REPORT", "DE_FAULT", "SCHEDULE", "SW_UPDATE"}
+	defNames     = []string{"None", "Unknown", "Broken", "NotAvailable", "Unstable", "Malfunction", "Anomalous", "Malformed"}
+
+	uinfoTextNames      = []string{"uplink_dest", "device_type", "device_name", "device_id"}
+	netRstTextNames     = []string{"default", "ap", "sc", "qc"}
+	netstatTextNames    = []string{"no_cfg", "no_conn", "no_uplink", "ok"}
+	netstatCfgTextNames = []string{"cfg_ap", "cfg_sc", "cfg_qc"}
+	tsyncTextNames      = []string{"utc", "local"}
+)
+
+const textTimeLayout = "2006-01-02T15:04:05"
+
+// Write packet in canonical text format
+func FormatText(p BasePkt) string {
+	var sb strings.Builder
+	if p.Ver != PgVer {
+		fmt.Fprintf(&sb, "ver=%d ", p.Ver)
+	}
+	sb.WriteString(byteName(cmdTextNames, p.CommandID))
+	body := textBody(p)
+	// Fall back to data bytes when typed body does not rebuild the same data
+	if data, err := appendTextData(nil, p.CommandID, body); err != nil || !bytes.Equal(data, p.Data) {
+		body = nil
+		if len(p.Data) > 0 {
+			body = []string{"#" + hex.EncodeToString(p.Data)}
+		}
+	}
+	for i, tok := range body {
+		if i == 0 || (body[i-1] != "[" && tok != "]") {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(tok)
+	}
+	return sb.String()
+}
+
+// Parse packet from canonical text format into packet owning its buffer
+// Packets without version use default pg version
+func ParseText(s string) (BasePkt, error) {
+	toks, err := textFields(s)
+	if err != nil {
+		return BasePkt{}, err
+	}
+	ver := PgVer
+	if len(toks) > 0 && strings.HasPrefix(toks[0], "ver=") {
+		v, err := strconv.ParseUint(toks[0][len("ver="):], 0, 8)
+		if err != nil {
+			return BasePkt{}, fmt.Errorf("%w: version %q", ErrInvalidData, toks[0])
+		}
+		ver, toks = byte(v), toks[1:]
+	}
+	if len(toks) == 0 {
+		return BasePkt{}, fmt.Errorf("%w: missing command", ErrInvalidData)
+	}
+	cid, err := parseByteName(cmdTextNames, toks[0], "command")
+	if err != nil {
+		return BasePkt{}, err
+	}
+
+	buf, start := AppendPktStartVer(nil, ver, cid)
+	if buf, err = appendTextData(buf, cid, toks[1:]); err != nil {
+		return BasePkt{}, err
+	}
+	if len(buf)-int(IdxData) > math.MaxUint16 {
+		return BasePkt{}, fmt.Errorf("%w: %d data bytes", ErrInvalidData, len(buf)-int(IdxData))
+	}
+	return Parse(AppendPktEnd(buf, start))
+}
+
+// Split text into whitespace separated tokens keeping quoted strings whole
+// Brackets outside quoted strings are tokens of their own
+func textFields(s string) ([]string, error) {
+	toks := []string{}
+	var tok strings.Builder
+	inTok, quoted, escaped := false, false, false
+	end := func() {
+		if inTok {
+			toks = append(toks, tok.String())
+			tok.Reset()
+			inTok = false
+		}
+	}
+	for _, r := range s {
+		switch {
+		case quoted:
+			tok.WriteRune(r)
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == '"' {
+				quoted = false
+			}
+		case unicode.IsSpace(r):
+			end()
+		case r == '[' || r == ']':
+			end()
+			toks = append(toks, string(r))
+		default:
+			tok.WriteRune(r)
+			inTok = true
+			quoted = r == '"'
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated string in %q", ErrInvalidData, s)
+	}
+	end()
+	return toks, nil
+}
+
+// Typed text body of packet, nil when packet has no typed form
+func textBody(p BasePkt) []string {
+	switch p.CommandID {
+	case CmdHandshake:
+		if len(p.Data) == 0 {
+			return []string{}
+		}
+		return []string{strconv.Quote(string(p.Data))}
+	case CmdUplinkInfo:
+		uinfo, err := p.GetUinfo()
+		if err != nil || uinfo.All {
+			return nil
+		}
+		body := []string{byteName(uinfoTextNames, uinfo.Rb)}
+		if uinfo.IsResp {
+			body = append(body, strconv.Quote(uinfo.Resp))
+		}
+		return body
+	case CmdNetworkReset:
+		nr, err := p.GetNetReset()
+		if err != nil {
+			return nil
+		}
+		if nr.Ack {
+			return []string{"ack"}
+		}
+		return []string{byteName(netRstTextNames, nr.Rb)}
+	case CmdNetworkStatus:
+		ns, err := p.GetNetStatus()
+		if err != nil {
+			return nil
+		}
+		if ns.Ack {
+			return []string{"ack"}
+		}
+		if ns.Data >= NetstatCfgAP {
+			return []string{byteName(netstatCfgTextNames, ns.Data-NetstatCfgAP)}
+		}
+		return []string{byteName(netstatTextNames, ns.Data)}
+	case CmdTimeSync:
+		ts, err := p.GetTsync()
+		if err != nil {
+			return nil
+		}
+		if ts.NotReady {
+			return []string{"not_ready"}
+		}
+		body := []string{byteName(tsyncTextNames, ts.Rb)}
+		if ts.IsResp {
+			body = append(body, ts.Time.Format(textTimeLayout))
+		}
+		return body
+	case CmdDESet, CmdDEReport:
+		depList, err := p.GetDEPList()
+		if err != nil {
+			return nil
+		}
+		body := []string{}
+		for _, dep := range depList {
+			body = append(body, formatTextDE(dep)...)
+		}
+		return body
+	case CmdDEFault:
+		def, err := p.GetDEFault()
+		if err != nil {
+			return nil
+		}
+		switch def.Kind {
+		case DefKindNoneAll:
+			return []string{"none"}
+		case DefKindAck:
+			return []string{"ack", formatTextDEAddr(def.Group, def.Id)}
+		case DefKindReport:
+			return []string{"report", formatTextDEAddr(def.Group, def.Id), strings.ToLower(byteName(defNames, def.Fault))}
+		}
+		return []string{}
+	case CmdSchedule:
+		sch, err := p.GetSch()
+		if err != nil {
+			return nil
+		}
+		switch sch.Kind {
+		case SchKindEraseAll:
+			return []string{"erase"}
+		case SchKindExecReport:
+			return []string{"exec", fmt.Sprintf("id=%d", sch.ExecId)}
+		}
+		body := []string{"set"}
+		for _, s := range sch.List {
+			body = append(body, "[", fmt.Sprintf("id=%d", s.Id), s.Wdays().String(), fmt.Sprintf("%02d:%02d", s.Hour, s.Minute))
+			body = append(append(body, formatTextDE(s.Dep)...), "]")
+		}
+		return body
+	case CmdSwUpdate:
+		swup, err := p.GetSwup()
+		if err != nil {
+			return nil
+		}
+		body := []string{strings.ToLower(byteName(swupScmdNames, swup.Scmd))}
+		switch swup.Scmd {
+		case SwupScmdSrep:
+			body = append(body, strings.ToLower(byteName(swupSrepNames, swup.Srep)))
+		case SwupScmdChunksz:
+			body = append(body, strconv.Itoa(int(swup.Chunk.Size)))
+		case SwupScmdStatus:
+			body = append(body, fmt.Sprintf("finish=%t", swup.Status.Finish), fmt.Sprintf("success=%t", swup.Status.Success),
+				"err="+strings.ToLower(byteName(swupErrNames, swup.Status.Err)))
+		case SwupScmdChunkReq:
+			body = append(body, fmt.Sprintf("idx=%d", swup.Chunk.Idx))
+		case SwupScmdChunk:
+			body = append(body, fmt.Sprintf("idx=%d", swup.Chunk.Idx), "#"+hex.EncodeToString(swup.Chunk.Data))
+		}
+		return body
+	}
+	return nil
+}
+
+// Append packet data written by text body toks to dst
+func appendTextData(dst []byte, cid CmdID, toks []string) ([]byte, error) {
+	if len(toks) > 0 && strings.HasPrefix(toks[0], "#") {
+		if len(toks) > 1 {
+			return nil, fmt.Errorf("%w: %q after data bytes", ErrInvalidData, toks[1])
+		}
+		return appendTextHex(dst, toks[0])
+	}
+	var err error
+	switch cid {
+	case CmdHandshake:
+		if len(toks) == 1 {
+			return appendTextQuoted(dst, toks[0])
+		}
+	case CmdUplinkInfo:
+		return appendTextUinfo(dst, toks)
+	case CmdNetworkReset, CmdNetworkStatus:
+		return appendTextNet(dst, cid, toks)
+	case CmdTimeSync:
+		return appendTextTsync(dst, toks)
+	case CmdDESet, CmdDEReport:
+		for len(toks) > 0 && err == nil {
+			if len(toks) < 2 {
+				return nil, fmt.Errorf("%w: DE %q without value", ErrInvalidData, toks[0])
+			}
+			dst, err = appendTextDE(dst, toks[0], toks[1])
+			toks = toks[2:]
+		}
+		return dst, err
+	case CmdDEFault:
+		return appendTextDEFault(dst, toks)
+	case CmdSchedule:
+		return appendTextSch(dst, toks)
+	case CmdSwUpdate:
+		return appendTextSwup(dst, toks)
+	}
+	if len(toks) > 0 {
+		return nil, fmt.Errorf("%w: %s body %q", ErrInvalidData, byteName(cmdTextNames, cid), toks[0])
+	}
+	return dst, nil
+}
+
+// Append bytes of quoted string to dst
+func appendTextQuoted(dst []byte, tok string) ([]byte, error) {
+	str, err := strconv.Unquote(tok)
+	if err != nil || !strings.HasPrefix(tok, `"`) {
+		return nil, fmt.Errorf("%w: expected quoted string but got %s", ErrInvalidData, tok)
+	}
+	return append(dst, str...), nil
+}
+
+// Append uplink info data written as [request byte ["response"]] to dst
+func appendTextUinfo(dst []byte, toks []string) ([]byte, error) {
+	if len(toks) == 0 {
+		return dst, nil
+	}
+	if len(toks) > 2 {
+		return nil, fmt.Errorf("%w: uplink info %q", ErrInvalidData, strings.Join(toks, " "))
+	}
+	rb, err := parseByteName(uinfoTextNames, toks[0], "device info request byte")
+	if err != nil {
+		return nil, err
+	}
+	dst = append(dst, rb)
+	if len(toks) == 2 {
+		return appendTextQuoted(dst, toks[1])
+	}
+	return dst, nil
+}
+
+// Append network reset or status data written as ack or request byte or status name to dst
+func appendTextNet(dst []byte, cid CmdID, toks []string) ([]byte, error) {
+	if len(toks) == 0 {
+		return dst, nil
+	}
+	if len(toks) > 1 {
+		return nil, fmt.Errorf("%w: %s %q", ErrInvalidData, byteName(cmdTextNames, cid), strings.Join(toks, " "))
+	}
+	if strings.EqualFold(toks[0], "ack") {
+		return dst, nil
+	}
+	if cid == CmdNetworkReset {
+		rb, err := parseByteName(netRstTextNames, toks[0], "network reset request byte")
+		if err != nil {
+			return nil, err
+		}
+		return append(dst, rb), nil
+	}
+	for i, name := range netstatCfgTextNames {
+		if strings.EqualFold(toks[0], name) {
+			return append(dst, NetstatCfgAP+byte(i)), nil
+		}
+	}
+	ns, err := parseByteName(netstatTextNames, toks[0], "network status")
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, ns), nil
+}
+
+// Append time synchronization data written as not_ready or request byte [time] to dst
+func appendTextTsync(dst []byte, toks []string) ([]byte, error) {
+	if len(toks) == 0 {
+		return dst, nil
+	}
+	if len(toks) > 2 {
+		return nil, fmt.Errorf("%w: time sync %q", ErrInvalidData, strings.Join(toks, " "))
+	}
+	if len(toks) == 1 && strings.EqualFold(toks[0], "not_ready") {
+		return dst, nil
+	}
+	rb, err := parseByteName(tsyncTextNames, toks[0], "time synchronization request byte")
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 1 {
+		return append(dst, rb), nil
+	}
+	tm, err := time.Parse(textTimeLayout, toks[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: time %q", ErrInvalidData, toks[1])
+	}
+	// Year is sent as one byte read back within 2000-2255
+	if tm.Year() < 2000 || tm.Year() > 2255 {
+		return nil, fmt.Errorf("%w: year %d outside 2000-2255", ErrInvalidData, tm.Year())
+	}
+	return append(dst, pktData(MkTsyncResp(rb, tm))...), nil
+}
+
+// Append bytes written in hex after '#' to dst
+func appendTextHex(dst []byte, tok string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(tok, "#"))
+	if err != nil || !strings.HasPrefix(tok, "#") {
+		return nil, fmt.Errorf("%w: data bytes %q", ErrInvalidData, tok)
+	}
+	return append(dst, data...), nil
+}
+
+func formatTextDEAddr(g DEGroup, id byte) string {
+	return fmt.Sprintf("%s/%d", strings.ToLower(groupName(g)), id)
+}
+
+func parseTextDEAddr(tok string) (DEGroup, byte, error) {
+	gs, ids, ok := strings.Cut(tok, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: DE %q is not group/id", ErrInvalidData, tok)
+	}
+	g, err := parseGroupName(gs)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := strconv.ParseUint(ids, 0, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: DE id %q", ErrInvalidData, ids)
+	}
+	return g, byte(id), nil
+}
+
+// Write DE parsed from packet as group/id and type=value tokens
+func formatTextDE(dep DePkt) []string {
+	toks := []string{formatTextDEAddr(dep.Group, dep.Id), ""}
+	wire := dep.Buf
+	name := strings.ToLower(typeName(dep.Dtype))
+	if value, ok := formatTextDEValue(dep); ok {
+		toks[1] = name + "=" + value
+		if back, err := appendTextDE(nil, toks[0], toks[1]); err == nil && bytes.Equal(back, wire) {
+			return toks
+		}
+	}
+	toks[1] = name + "=#" + hex.EncodeToString(wire[LenDePktMin:])
+	return toks
+}
+
+func formatTextDEValue(dep DePkt) (string, bool) {
+	if dep.checkDlen() != nil {
+		return "", false
+	}
+	switch dep.Dtype {
+	case DEtypeString:
+		return strconv.Quote(string(dep.DataRaw[:dep.Dlen])), true
+	case DEtypeBool:
+		return strconv.FormatBool(dep.Data != 0), true
+	case DEtypeEnum, DEtypeUint:
+		return strconv.FormatUint(uint64(dep.Data), 10), true
+	case DEtypeBmap1, DEtypeBmap2, DEtypeBmap4:
+		return fmt.Sprintf("0b%0*b", BmapWidth(dep.Dtype), dep.Data), true
+	case DEtypeInt8, DEtypeInt16, DEtypeInt32:
+		return strconv.FormatInt(int64(DepIntData(dep)), 10), true
+	case DEtypeFloat:
+		return strconv.FormatFloat(float64(math.Float32frombits(dep.Data)), 'g', -1, 32), true
+	case DEtypeFixed:
+		if len(dep.DataRaw) < int(LenDeFixed) {
+			return "", false
+		}
+		_, scale, offset := DepFixedPoint(dep)
+		return fmt.Sprintf("%s,scale=%s,offset=%s", strconv.FormatFloat(DepFloatData(dep), 'g', -1, 64),
+			strconv.FormatFloat(float64(scale), 'g', -1, 32), strconv.FormatFloat(float64(offset), 'g', -1, 32)), true
+	}
+	return "", false
+}
+
+// Append DE written as group/id and type=value tokens to dst
+func appendTextDE(dst []byte, addr string, value string) ([]byte, error) {
+	g, id, err := parseTextDEAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	ts, vs, ok := strings.Cut(value, "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: DE value %q is not type=value", ErrInvalidData, value)
+	}
+	t, err := parseTypeName(ts)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(vs, "#") {
+		data, err := appendTextHex(nil, vs)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > math.MaxUint16 {
+			return nil, fmt.Errorf("%w: %d DE data bytes", ErrInvalidData, len(data))
+		}
+		dst = appendDEHead(dst, g, id, t, uint16(len(data)))
+		return append(dst, data...), nil
+	}
+
+	dep := DePkt{Group: g, Id: id, Dtype: t}
+	if err = parseTextDEValue(&dep, vs); err != nil {
+		if !errors.Is(err, ErrInvalidData) {
+			err = fmt.Errorf("%w: %s value %q: %w", ErrInvalidData, t, vs, err)
+		}
+		return nil, err
+	}
+	return AppendDEP(dst, dep), nil
+}
+
+func parseTextDEValue(dep *DePkt, s string) error {
+	var err error
+	var v uint64
+	var i int64
+	var f float64
+	switch dep.Dtype {
+	case DEtypeString:
+		var str string
+		if str, err = strconv.Unquote(s); err == nil && len(str) > math.MaxUint16 {
+			err = fmt.Errorf("%d bytes", len(str))
+		}
+		dep.DataRaw = []byte(str)
+	case DEtypeBool:
+		switch s {
+		case "true":
+			dep.Data = 1
+		case "false":
+		default:
+			err = fmt.Errorf("not true or false")
+		}
+	case DEtypeEnum:
+		v, err = strconv.ParseUint(s, 0, 8)
+		dep.Data = uint32(v)
+	case DEtypeUint:
+		v, err = strconv.ParseUint(s, 0, 32)
+		dep.Data = uint32(v)
+	case DEtypeBmap1, DEtypeBmap2, DEtypeBmap4:
+		v, err = strconv.ParseUint(s, 0, BmapWidth(dep.Dtype))
+		dep.Data = uint32(v)
+	case DEtypeInt8, DEtypeInt16, DEtypeInt32:
+		i, err = strconv.ParseInt(s, 0, 8*int(EnforceDElen(dep.Dtype, 0)))
+		dep.Data = uint32(i)
+	case DEtypeFloat:
+		f, err = strconv.ParseFloat(s, 32)
+		dep.Data = math.Float32bits(float32(f))
+	case DEtypeFixed:
+		return parseTextFixed(dep, s)
+	default:
+		err = fmt.Errorf("only data bytes")
+	}
+	dep.Dlen = EnforceDElen(dep.Dtype, uint16(len(dep.DataRaw)))
+	return err
+}
+
+// Parse fixed-point value written as value,scale=S,offset=O
+func parseTextFixed(dep *DePkt, s string) error {
+	parts := strings.Split(s, ",")
+	v, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return err
+	}
+	var scale, offset float64
+	for _, part := range parts[1:] {
+		key, val, _ := strings.Cut(part, "=")
+		f, err := strconv.ParseFloat(val, 32)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "scale":
+			scale = f
+		case "offset":
+			offset = f
+		default:
+			return fmt.Errorf("unknown %q", key)
+		}
+	}
+	if dep.DataRaw, err = FixedToBslice(v, float32(scale), float32(offset)); err != nil {
+		return err
+	}
+	dep.Dlen = LenDeFixed
+	return nil
+}
+
+// Append DE fault data written as [none | ack group/id | report group/id fault] to dst
+func appendTextDEFault(dst []byte, toks []string) ([]byte, error) {
+	if len(toks) == 0 {
+		return dst, nil
+	}
+	want := map[string]int{"none": 1, "ack": 2, "report": 3}[strings.ToLower(toks[0])]
+	if want == 0 || len(toks) != want {
+		return nil, fmt.Errorf("%w: DE fault %q", ErrInvalidData, strings.Join(toks, " "))
+	}
+	if want == 1 {
+		return append(dst, DefNone), nil
+	}
+	g, id, err := parseTextDEAddr(toks[1])
+	if err != nil {
+		return nil, err
+	}
+	dst = append(dst, byte(g), id)
+	if want == 3 {
+		f, err := parseByteName(defNames, toks[2], "DE fault")
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, f)
+	}
+	return dst, nil
+}
+
+// Parse id=N token
+func parseTextId(tok string, key string, bits int) (uint64, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(tok, key+"="), 0, bits)
+	if err != nil || !strings.HasPrefix(tok, key+"=") {
+		return 0, fmt.Errorf("%w: expected %s=N but got %q", ErrInvalidData, key, tok)
+	}
+	return v, nil
+}
+
+// Append schedule data written as erase, exec id=N or set followed by bracketed schedules to dst
+func appendTextSch(dst []byte, toks []string) ([]byte, error) {
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("%w: missing schedule kind", ErrInvalidData)
+	}
+	switch strings.ToLower(toks[0]) {
+	case "erase":
+		if len(toks) == 1 {
+			return dst, nil
+		}
+	case "exec":
+		if len(toks) == 2 {
+			id, err := parseTextId(toks[1], "id", 8)
+			if err != nil {
+				return nil, err
+			}
+			return append(dst, byte(id)), nil
+		}
+	case "set":
+		return appendTextSchSet(dst, toks[1:])
+	}
+	return nil, fmt.Errorf("%w: schedule %q", ErrInvalidData, strings.Join(toks, " "))
+}
+
+// Append schedules written as [id=N weekdays HH:MM group/id type=value] ... to dst
+func appendTextSchSet(dst []byte, toks []string) ([]byte, error) {
+	const lenSchToks = 7
+	countIdx := len(dst)
+	dst = append(dst, 0)
+	for n := 0; len(toks) > 0; n++ {
+		if n > math.MaxUint8 {
+			return nil, fmt.Errorf("%w: more than %d schedules", ErrSchedule, math.MaxUint8)
+		}
+		if len(toks) < lenSchToks || toks[0] != "[" || toks[lenSchToks-1] != "]" {
+			return nil, fmt.Errorf("%w: schedule %d is not [id=N weekdays HH:MM group/id type=value]", ErrSchedule, n)
+		}
+		id, err := parseTextId(toks[1], "id", 8)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSchedule, err)
+		}
+		w, err := parseTextWeekdays(toks[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSchedule, err)
+		}
+		hs, ms, _ := strings.Cut(toks[3], ":")
+		hour, herr := strconv.ParseUint(hs, 10, 8)
+		minute, merr := strconv.ParseUint(ms, 10, 8)
+		if herr != nil || merr != nil {
+			return nil, fmt.Errorf("%w: time %q", ErrSchedule, toks[3])
+		}
+		sch := SchPkt{Id: byte(id), Weekdays: byte(w), Hour: byte(hour), Minute: byte(minute)}
+		if err = sch.Validate(); err != nil {
+			return nil, err
+		}
+		dst = append(dst, sch.Id, sch.Weekdays, sch.Hour, sch.Minute)
+		if dst, err = appendTextDE(dst, toks[4], toks[5]); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSchedule, err)
+		}
+		dst[countIdx]++
+		toks = toks[lenSchToks:]
+	}
+	return dst, nil
+}
+
+// Parse weekdays as written by Weekdays String method
+func parseTextWeekdays(s string) (Weekdays, error) {
+	if strings.EqualFold(s, "None") {
+		return 0, nil
+	}
+	return ParseWeekdays(s)
+}
+
+// Append software update data written as subcommand and its fields to dst
+func appendTextSwup(dst []byte, toks []string) ([]byte, error) {
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("%w: missing swup subcommand", ErrInvalidData)
+	}
+	scmd, err := parseByteName(swupScmdNames, toks[0], "swup subcommand")
+	if err != nil {
+		return nil, err
+	}
+	swup := Swup{Scmd: scmd}
+	args := toks[1:]
+	want := []int{0, 1, 1, 3, 1, 2}
+	if int(scmd) >= len(want) || len(args) != want[scmd] {
+		return nil, fmt.Errorf("%w: swup %q", ErrInvalidData, strings.Join(toks, " "))
+	}
+	switch scmd {
+	case SwupScmdSrep:
+		swup.Srep, err = parseByteName(swupSrepNames, args[0], "swup simple reply")
+	case SwupScmdChunksz:
+		var v uint64
+		v, err = strconv.ParseUint(args[0], 0, 16)
+		swup.Chunk.Size = uint16(v)
+	case SwupScmdStatus:
+		for _, arg := range args {
+			key, val, _ := strings.Cut(arg, "=")
+			switch key {
+			case "finish":
+				swup.Status.Finish, err = strconv.ParseBool(val)
+			case "success":
+				swup.Status.Success, err = strconv.ParseBool(val)
+			case "err":
+				swup.Status.Err, err = parseByteName(swupErrNames, val, "swup error")
+			default:
+				err = fmt.Errorf("unknown %q", key)
+			}
+			if err != nil {
+				break
+			}
+		}
+	case SwupScmdChunkReq, SwupScmdChunk:
+		var v uint64
+		v, err = parseTextId(args[0], "idx", 32)
+		swup.Chunk.Idx = uint32(v)
+		if err == nil && scmd == SwupScmdChunk {
+			swup.Chunk.Data, err = appendTextHex(nil, args[1])
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: swup %q: %w", ErrInvalidData, strings.Join(toks, " "), err)
+	}
+	return appendSwupData(dst, swup)
+}
@@ -0,0 +1,142 @@
+package pg
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestText(t *testing.T) {
+	SetVer(0)
+	sch := []SchPkt{{Id: 1, Weekdays: 0b0001010, Hour: 7, Minute: 30, Dep: DePkt{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 1}}}
+	cases := []struct {
+		buf  []byte
+		text string
+	}{
+		{MkDeSetUint(DegControl, 3, 42), "DE_SET control/3 uint=42"},
+		{MkDeRepStr(DegInfo, 0, "SN 1234\""), `DE_REPORT info/0 string="SN 1234\""`},
+		{MkDeRepFixed(DegSensor, 1, -12.3, 0.1, -40), "DE_REPORT sensor/1 fixed=-12.3,scale=0.1,offset=-40"},
+		{MkDeRepBmap1(DegControl, 2, 0x81), "DE_REPORT control/2 bmap1=0b10000001"},
+		{MkDeRepInt16(DegSensor, 2, -300), "DE_REPORT sensor/2 int16=-300"},
+		{MkDeRepFloat(DegSensor, 3, 1.5), "DE_REPORT sensor/3 float=1.5"},
+		{MkDESList([]DePkt{{Group: DegControl, Id: 1, Dtype: DEtypeBool, Data: 2}, {Group: 7, Id: 2, Dtype: DEtypeRaw, Dlen: 2, DataRaw: []byte{1, 2}}}),
+			"DE_SET control/1 bool=#02 0x07/2 raw=#0102"},
+		{MkDESList([]DePkt{{Group: DegControl, Id: 9, Dtype: 0x20, Dlen: 1, DataRaw: []byte{5}}}), "DE_SET control/9 0x20=#05"},
+		{MkDESList(nil), "DE_SET"},
+		{MkDeFaultRep(DegSensor, 1, DefBroken), "DE_FAULT report sensor/1 broken"},
+		{MkSchSet(sch), "SCHEDULE set [id=1 Mon,Wed 07:30 control/1 bool=true]"},
+		{MkSchExecReport(4), "SCHEDULE exec id=4"},
+		{MkSchEraseAllReq(), "SCHEDULE erase"},
+		{MkSwupInitiate(), "SW_UPDATE initiate"},
+		{MkSwupSrep(SrepBusy), "SW_UPDATE srep busy"},
+		{MkSwupSetChunksz(256), "SW_UPDATE chunksz 256"},
+		{MkSwupStatus(true, false, SwupErrOom), "SW_UPDATE status finish=true success=false err=oom"},
+		{MkSwupChunkReq(3), "SW_UPDATE chunkreq idx=3"},
+		{MkSwupChunk(3, []byte("chunk")), "SW_UPDATE chunk idx=3 #6368756e6b"},
+		{MkHandshake([]byte("pg")), `HANDSHAKE "pg"`},
+		{MkHandshake([]byte{0xff, '"'}), `HANDSHAKE "\xff\""`},
+		{MkHandshake(nil), "HANDSHAKE"},
+		{MkUinfoReqAll(), "UPLINK_INFO"},
+		{MkUinfoReq(DeviceName), "UPLINK_INFO device_name"},
+		{MkUinfoResp(DeviceName, "Ceiling Fan"), `UPLINK_INFO device_name "Ceiling Fan"`},
+		{MkNetResetReq(NetSC), "NETWORK_RESET sc"},
+		{MkNetResetACK(), "NETWORK_RESET ack"},
+		{MkNetStatusReport(NetstatNoUplink), "NETWORK_STATUS no_uplink"},
+		{MkNetStatusReport(NetstatCfgSC), "NETWORK_STATUS cfg_sc"},
+		{MkNetStatusReportACK(), "NETWORK_STATUS ack"},
+		{MkTsyncNotReady(), "TIME_SYNC not_ready"},
+		{MkTsyncReq(TsyncUTC), "TIME_SYNC utc"},
+		{MkTsyncResp(TsyncUTC, time.Date(2024, time.March, 4, 7, 30, 0, 0, time.UTC)), "TIME_SYNC utc 2024-03-04T07:30:00"},
+		{MkTsyncResp(TsyncLocal, time.Date(2024, time.March, 4, 7, 30, 0, 0, time.Local)), "TIME_SYNC local 2024-03-04T07:30:00"},
+	}
+	for _, c := range cases {
+		p, _ := Parse(c.buf)
+		if text := FormatText(p); text != c.text {
+			t.Errorf("expected %s but got %s", c.text, text)
+		}
+		back, err := ParseText(c.text)
+		if err != nil || !bytes.Equal(back.Buf, c.buf) {
+			t.Errorf("%s: expected %x but got %x %v", c.text, c.buf, back.Buf, err)
+		}
+	}
+
+	// Every seed packet round-trips, including version
+	for _, buf := range fuzzSeedPkts() {
+		SetPktVer(buf, 2)
+		p, _ := Parse(buf)
+		text := FormatText(p)
+		back, err := ParseText(text)
+		if err != nil || !bytes.Equal(back.Buf, buf) {
+			t.Errorf("%s: expected %x but got %x %v", text, buf, back.Buf, err)
+		}
+	}
+
+	// Loose spacing, case and number forms
+	p, err := ParseText("  de_set Control/0x03 UINT=0x2a\tsensor/1 fixed=20,offset=-40,scale=0.1 ")
+	want := MkDESList([]DePkt{
+		{Group: DegControl, Id: 3, Dtype: DEtypeUint, Data: 42},
+		{Group: DegSensor, Id: 1, Dtype: DEtypeFixed, Dlen: LenDeFixed, DataRaw: mustFixed(20, 0.1, -40)},
+	})
+	if err != nil || !bytes.Equal(p.Buf, want) {
+		t.Errorf("expected %x but got %x %v", want, p.Buf, err)
+	}
+	p, err = ParseText("SCHEDULE set [id=2 Mon-Fri 7:05 control/1 enum=3][id=3 Sun 23:59 control/2 bool=false]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schList, _ := p.GetSchList(); len(schList) != 2 || schList[0].Weekdays != 0b0111110 || schList[0].Minute != 5 || schList[1].Weekdays != 1 {
+		t.Error(schList)
+	}
+	// Schedules failing validation are written as data bytes
+	p, _ = Parse(MkSchSet([]SchPkt{{Id: 1, Weekdays: byte(WeekdaysReserved | 1), Hour: 24, Dep: sch[0].Dep}}))
+	if text := FormatText(p); !strings.HasPrefix(text, "SCHEDULE #") {
+		t.Error(text)
+	}
+
+	bad := []string{
+		"",
+		"NOPE",
+		"ver=256 DE_SET",
+		"DE_SET control/3",
+		"DE_SET control uint=1",
+		"DE_SET control/3 uint=-1",
+		"DE_SET control/3 int8=128",
+		"DE_SET control/3 bmap1=0x100",
+		"DE_SET control/3 bool=yes",
+		"DE_SET control/3 raw=1",
+		"DE_SET control/3 fixed=1",
+		"DE_SET control/3 fixed=1e40,scale=1e-30",
+		"DE_SET control/3 fixed=1,scale=0",
+		`DE_SET info/0 string="open`,
+		"HANDSHAKE pg",
+		"UPLINK_INFO device_name fan",
+		"UPLINK_INFO nope",
+		"NETWORK_RESET ack ack",
+		"NETWORK_STATUS cfg_nope",
+		"TIME_SYNC utc 2024-03-04",
+		"TIME_SYNC utc 1999-03-04T07:30:00",
+		"TIME_SYNC gmt",
+		"HANDSHAKE #7",
+		"DE_FAULT report sensor/1",
+		"SCHEDULE",
+		"SCHEDULE exec",
+		"SCHEDULE set [id=1 Funday 07:30 control/1 bool=true]",
+		"SCHEDULE set [id=1 Mon 0730 control/1 bool=true]",
+		"SCHEDULE set [id=1 Mon 25:99 control/1 bool=true]",
+		"SCHEDULE set [id=1 Mon,Bit7 07:30 control/1 bool=true]",
+		"SCHEDULE exec id=256",
+		"SCHEDULE set [id=1 Mon 07:30 control/1 bool=true",
+		"SW_UPDATE chunk idx=1",
+		"SW_UPDATE chunk idx=1 #",
+		"SW_UPDATE status finish=true success=true bad=1",
+	}
+	for _, s := range bad {
+		if _, err := ParseText(s); !errors.Is(err, ErrInvalidData) && !errors.Is(err, ErrSchedule) {
+			t.Errorf("%q: expected error but got %v", s, err)
+		} else {
+			t.Logf("%q: %v", s, err)
+		}
+	}
+}